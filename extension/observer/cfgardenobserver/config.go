@@ -6,6 +6,7 @@ package cfgardenobserver // import "github.com/open-telemetry/opentelemetry-coll
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 )
 
@@ -27,25 +28,102 @@ type Config struct {
 	// Default: "5m"
 	CacheSyncInterval time.Duration `mapstructure:"cache_sync_interval"`
 
+	// JitterFactor extends RefreshInterval and CacheSyncInterval by a random duration of
+	// up to this fraction of the interval, so collectors running on many cells do not
+	// synchronize their Garden and CloudFoundry API calls. The jitter is drawn once when
+	// the observer is created, each instance keeps its own fixed intervals. Must be between 0 and 1.
+	// Default: 0
+	JitterFactor float64 `mapstructure:"jitter_factor"`
+
 	// Determines whether or not Application labels get added to the Endpoint labels.
 	// This requires cloud_foundry to be configured, such that API calls can be made
 	// Default: false
 	IncludeAppLabels bool `mapstructure:"include_app_labels"`
+
+	// UseHostBindings determines whether endpoints target the cell IP and the host
+	// port mapped to the container port, instead of the container overlay IP.
+	// Default: false
+	UseHostBindings bool `mapstructure:"use_host_bindings"`
+
+	// StableEndpointIDs determines whether endpoint IDs are derived from the
+	// application process and instance index instead of the container handle,
+	// so the ID of an instance stays the same when its container is recreated.
+	// Default: false
+	StableEndpointIDs bool `mapstructure:"stable_endpoint_ids"`
+
+	// Determines whether the ports reported by the CloudFoundry process stats are
+	// added to the ports registered in the container properties, to discover apps
+	// listening on extra ports. This requires cloud_foundry to be configured.
+	// Default: false
+	DiscoverExtraPorts bool `mapstructure:"discover_extra_ports"`
+
+	// Determines whether containers of applications which are STOPPED according to
+	// the CloudFoundry API are excluded, even while Garden still lists them, so
+	// endpoints of dying instances are removed before their containers are destroyed.
+	// This requires cloud_foundry to be configured.
+	// Default: false
+	ExcludeStoppedApps bool `mapstructure:"exclude_stopped_apps"`
+
+	// Regular expressions matched against container handles. When IncludeHandles is
+	// set, only matching containers are observed. Containers matching ExcludeHandles
+	// are never observed, e.g. `^executor-healthcheck-`.
+	// Default: []
+	IncludeHandles []string `mapstructure:"include_handles"`
+	ExcludeHandles []string `mapstructure:"exclude_handles"`
+
+	// MinStableObservations is the number of consecutive refreshes a container must be
+	// listed in before its endpoints are emitted, so freshly created containers are not
+	// targeted by receivers while the app is still starting. Values of 0 and 1 emit
+	// endpoints as soon as the container is listed.
+	// Default: 0
+	MinStableObservations int `mapstructure:"min_stable_observations"`
+
+	// IncludeRecentlyStopped is the grace period during which the endpoints of a container
+	// are still emitted after it stopped, flagged with the `state: stopped` label, so receivers
+	// can finish draining its logs before they are torn down. A value of 0 removes the
+	// endpoints as soon as the container stops.
+	// Default: 0
+	IncludeRecentlyStopped time.Duration `mapstructure:"include_recently_stopped"`
+
+	// DebugEndpoint is the address of a local HTTP server exposing a JSON snapshot
+	// of the currently discovered endpoints and the last sync errors. It is meant
+	// for troubleshooting and is disabled when empty.
+	// Default: ""
+	DebugEndpoint string `mapstructure:"debug_endpoint"`
 }
 
 // Validate overrides the embedded noop validation so that load config can trigger
 // our own validation logic.
 func (config *Config) Validate() error {
-	if !config.IncludeAppLabels {
+	if _, err := compileHandlePatterns(config.IncludeHandles); err != nil {
+		return fmt.Errorf("invalid include_handles: %w", err)
+	}
+	if _, err := compileHandlePatterns(config.ExcludeHandles); err != nil {
+		return fmt.Errorf("invalid exclude_handles: %w", err)
+	}
+
+	if config.JitterFactor < 0 || config.JitterFactor > 1 {
+		return fmt.Errorf("jitter_factor must be between 0 and 1. Specified value: %v", config.JitterFactor)
+	}
+
+	if config.MinStableObservations < 0 {
+		return errors.New("min_stable_observations must not be negative")
+	}
+
+	if config.IncludeRecentlyStopped < 0 {
+		return errors.New("include_recently_stopped must not be negative")
+	}
+
+	if !config.usesCloudFoundry() {
 		return nil
 	}
 
 	c := config.CloudFoundry
 	if c.Endpoint == "" {
-		return errors.New("CloudFoundry.Endpoint must be specified when IncludeAppLabels is set to true")
+		return errors.New("CloudFoundry.Endpoint must be specified when IncludeAppLabels, DiscoverExtraPorts or ExcludeStoppedApps is set to true")
 	}
 	if c.Auth.Type == "" {
-		return errors.New("CloudFoundry.Auth.Type must be specified when IncludeAppLabels is set to true")
+		return errors.New("CloudFoundry.Auth.Type must be specified when IncludeAppLabels, DiscoverExtraPorts or ExcludeStoppedApps is set to true")
 	}
 
 	switch c.Auth.Type {
@@ -77,6 +155,23 @@ func (config *Config) Validate() error {
 	return nil
 }
 
+// usesCloudFoundry returns whether any of the enabled features requires the CloudFoundry API
+func (config *Config) usesCloudFoundry() bool {
+	return config.IncludeAppLabels || config.DiscoverExtraPorts || config.ExcludeStoppedApps
+}
+
+func compileHandlePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
 func fieldError(authType authType, param string) error {
 	return fmt.Errorf("%s is required when using auth_type: %s", param, authType)
 }
@@ -55,6 +55,34 @@ func TestLoadConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			id: component.NewIDWithName(metadata.Type, "discovery_settings"),
+			expected: &Config{
+				RefreshInterval:        1 * time.Minute,
+				CacheSyncInterval:      5 * time.Minute,
+				JitterFactor:           0.1,
+				DebugEndpoint:          "localhost:55690",
+				UseHostBindings:        true,
+				StableEndpointIDs:      true,
+				DiscoverExtraPorts:     true,
+				ExcludeStoppedApps:     true,
+				MinStableObservations:  2,
+				IncludeRecentlyStopped: 30 * time.Second,
+				IncludeHandles:         []string{"^[0-9a-f-]+$"},
+				ExcludeHandles:         []string{"^executor-healthcheck-"},
+				Garden: GardenConfig{
+					Endpoint: "/var/vcap/data/garden/garden.sock",
+				},
+				CloudFoundry: CfConfig{
+					Endpoint: "https://api.cf.mydomain.com",
+					Auth: CfAuth{
+						Type:     "user_pass",
+						Username: "myuser",
+						Password: "mypass",
+					},
+				},
+			},
+		},
 		{
 			id: component.NewIDWithName(metadata.Type, "user_pass"),
 			expected: &Config{
@@ -133,7 +161,58 @@ func TestConfigValidate(t *testing.T) {
 			cfg: Config{
 				IncludeAppLabels: true,
 			},
-			msg: "CloudFoundry.Endpoint must be specified when IncludeAppLabels is set to true",
+			msg: "CloudFoundry.Endpoint must be specified when IncludeAppLabels, DiscoverExtraPorts or ExcludeStoppedApps is set to true",
+		},
+		{
+			reason: "invalid include_handles",
+			cfg: Config{
+				IncludeHandles: []string{"("},
+			},
+			msg: "invalid include_handles: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			reason: "invalid exclude_handles",
+			cfg: Config{
+				ExcludeHandles: []string{"[a-"},
+			},
+			msg: "invalid exclude_handles: error parsing regexp: missing closing ]: `[a-`",
+		},
+		{
+			reason: "missing endpoint with discover_extra_ports",
+			cfg: Config{
+				DiscoverExtraPorts: true,
+				IncludeHandles:     []string{"^[0-9a-f-]+$"},
+				ExcludeHandles:     []string{"^executor-healthcheck-"},
+			},
+			msg: "CloudFoundry.Endpoint must be specified when IncludeAppLabels, DiscoverExtraPorts or ExcludeStoppedApps is set to true",
+		},
+		{
+			reason: "jitter_factor out of range",
+			cfg: Config{
+				JitterFactor: 1.5,
+			},
+			msg: "jitter_factor must be between 0 and 1. Specified value: 1.5",
+		},
+		{
+			reason: "negative min_stable_observations",
+			cfg: Config{
+				MinStableObservations: -1,
+			},
+			msg: "min_stable_observations must not be negative",
+		},
+		{
+			reason: "negative include_recently_stopped",
+			cfg: Config{
+				IncludeRecentlyStopped: -time.Second,
+			},
+			msg: "include_recently_stopped must not be negative",
+		},
+		{
+			reason: "missing endpoint with exclude_stopped_apps",
+			cfg: Config{
+				ExcludeStoppedApps: true,
+			},
+			msg: "CloudFoundry.Endpoint must be specified when IncludeAppLabels, DiscoverExtraPorts or ExcludeStoppedApps is set to true",
 		},
 		{
 			reason: "missing cloud_foundry.auth.type",
@@ -143,7 +222,7 @@ func TestConfigValidate(t *testing.T) {
 					Endpoint: "https://api.cf.mydomain.com",
 				},
 			},
-			msg: "CloudFoundry.Auth.Type must be specified when IncludeAppLabels is set to true",
+			msg: "CloudFoundry.Auth.Type must be specified when IncludeAppLabels, DiscoverExtraPorts or ExcludeStoppedApps is set to true",
 		},
 		{
 			reason: "unknown cloud_foundry.auth.type",
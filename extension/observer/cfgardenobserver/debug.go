@@ -0,0 +1,113 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cfgardenobserver // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/observer/cfgardenobserver"
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/observer"
+)
+
+const (
+	debugEndpointsPath = "/endpoints"
+
+	syncSourceGarden       = "garden"
+	syncSourceCloudFoundry = "cloud_foundry"
+)
+
+// debugState keeps track of the latest observed endpoints and sync errors,
+// so they can be exposed through the debug endpoint.
+type debugState struct {
+	mu         sync.RWMutex
+	listedAt   time.Time
+	endpoints  []observer.Endpoint
+	syncErrors map[string]syncError
+}
+
+type syncError struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+}
+
+type endpointsSnapshot struct {
+	ListedAt   time.Time              `json:"listed_at"`
+	Endpoints  []observer.EndpointEnv `json:"endpoints"`
+	SyncErrors map[string]syncError   `json:"sync_errors"`
+}
+
+func newDebugState() *debugState {
+	return &debugState{
+		syncErrors: make(map[string]syncError),
+	}
+}
+
+func (d *debugState) setEndpoints(endpoints []observer.Endpoint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listedAt = time.Now()
+	d.endpoints = endpoints
+}
+
+func (d *debugState) setSyncError(source string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.syncErrors[source] = syncError{Time: time.Now(), Error: err.Error()}
+}
+
+func (d *debugState) snapshot() endpointsSnapshot {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	s := endpointsSnapshot{
+		ListedAt:   d.listedAt,
+		Endpoints:  make([]observer.EndpointEnv, 0, len(d.endpoints)),
+		SyncErrors: make(map[string]syncError, len(d.syncErrors)),
+	}
+	for _, e := range d.endpoints {
+		env, err := e.Env()
+		if err != nil {
+			continue
+		}
+		s.Endpoints = append(s.Endpoints, env)
+	}
+	for k, v := range d.syncErrors {
+		s.SyncErrors[k] = v
+	}
+	return s
+}
+
+func (d *debugState) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (g *cfGardenObserver) startDebugServer() error {
+	ln, err := net.Listen("tcp", g.config.DebugEndpoint)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(debugEndpointsPath, g.debug)
+	g.debugServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := g.debugServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			g.logger.Error("debug server stopped unexpectedly", zap.Error(err))
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cfgardenobserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/observer"
+)
+
+func TestDebugStateServeHTTP(t *testing.T) {
+	state := newDebugState()
+	state.setEndpoints([]observer.Endpoint{
+		{
+			ID:     "handle:8080",
+			Target: "1.2.3.4:8080",
+			Details: &observer.Container{
+				Name:        "handle",
+				ContainerID: "handle",
+				Host:        "1.2.3.4",
+				Port:        8080,
+				Transport:   observer.ProtocolTCP,
+				Labels:      map[string]string{"app_name": "myapp"},
+			},
+		},
+	})
+	state.setSyncError(syncSourceCloudFoundry, errors.New("api unavailable"))
+
+	rec := httptest.NewRecorder()
+	state.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, debugEndpointsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var got struct {
+		Endpoints  []map[string]any     `json:"endpoints"`
+		SyncErrors map[string]syncError `json:"sync_errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+	require.Len(t, got.Endpoints, 1)
+	require.Equal(t, "handle:8080", got.Endpoints[0]["id"])
+	require.Equal(t, "1.2.3.4:8080", got.Endpoints[0]["endpoint"])
+	require.Equal(t, "container", got.Endpoints[0]["type"])
+	require.Equal(t, map[string]any{"app_name": "myapp"}, got.Endpoints[0]["labels"])
+
+	require.Len(t, got.SyncErrors, 1)
+	require.Equal(t, "api unavailable", got.SyncErrors[syncSourceCloudFoundry].Error)
+}
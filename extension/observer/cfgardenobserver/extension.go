@@ -7,6 +7,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -32,6 +36,19 @@ const (
 	propertiesLogConfigKey = "log_config"
 	logConfigTagsKey       = "tags"
 	containerStateActive   = "active"
+	containerStateStopped  = "stopped"
+	appStateStopped        = "STOPPED"
+
+	labelContainerIP   = "container_ip"
+	labelState         = "state"
+	labelHostIP        = "host_ip"
+	labelInstanceIndex = "instance_index"
+	logConfigIndexKey  = "index"
+	tagsInstanceIDKey  = "instance_id"
+	tagsProcessIDKey   = "process_id"
+	tagsAppIDKey       = "app_id"
+
+	processStatsInternalPortKey = "internal"
 )
 
 type cfGardenObserver struct {
@@ -49,20 +66,66 @@ type cfGardenObserver struct {
 
 	appMu sync.RWMutex
 	apps  map[string]*resource.App
+
+	processMu    sync.RWMutex
+	processStats map[string]*resource.ProcessStats
+
+	observationMu sync.Mutex
+	observations  map[string]int
+
+	retainMu sync.Mutex
+	active   map[string][]observer.Endpoint
+	stopped  map[string]stoppedContainer
+	now      func() time.Time
+
+	debug       *debugState
+	debugServer *http.Server
+
+	includeHandles []*regexp.Regexp
+	excludeHandles []*regexp.Regexp
+
+	// cacheSyncInterval is the CacheSyncInterval extended by the jitter of this instance
+	cacheSyncInterval time.Duration
+}
+
+// stoppedContainer holds the endpoints of a container which is no longer active,
+// retained until the IncludeRecentlyStopped grace period since it stopped elapses
+type stoppedContainer struct {
+	endpoints []observer.Endpoint
+	since     time.Time
 }
 
 var _ extension.Extension = (*cfGardenObserver)(nil)
 
 func newObserver(config *Config, logger *zap.Logger) (extension.Extension, error) {
+	includeHandles, err := compileHandlePatterns(config.IncludeHandles)
+	if err != nil {
+		return nil, err
+	}
+	excludeHandles, err := compileHandlePatterns(config.ExcludeHandles)
+	if err != nil {
+		return nil, err
+	}
+
 	g := &cfGardenObserver{
-		config:     config,
-		logger:     logger,
-		once:       &sync.Once{},
-		containers: make(map[string]garden.ContainerInfo),
-		apps:       make(map[string]*resource.App),
-		doneChan:   make(chan struct{}),
-	}
-	g.EndpointsWatcher = endpointswatcher.New(g, config.RefreshInterval, logger)
+		config:         config,
+		logger:         logger,
+		once:           &sync.Once{},
+		containers:     make(map[string]garden.ContainerInfo),
+		apps:           make(map[string]*resource.App),
+		processStats:   make(map[string]*resource.ProcessStats),
+		observations:   make(map[string]int),
+		active:         make(map[string][]observer.Endpoint),
+		stopped:        make(map[string]stoppedContainer),
+		now:            time.Now,
+		doneChan:       make(chan struct{}),
+		debug:          newDebugState(),
+		includeHandles: includeHandles,
+		excludeHandles: excludeHandles,
+	}
+	// Both intervals are jittered once, so each instance polls with its own fixed period.
+	g.cacheSyncInterval = jitter(config.CacheSyncInterval, config.JitterFactor)
+	g.EndpointsWatcher = endpointswatcher.New(g, jitter(config.RefreshInterval, config.JitterFactor), logger)
 	return g, nil
 }
 
@@ -73,6 +136,7 @@ func (g *cfGardenObserver) SyncApps() error {
 
 	g.appMu.Lock()
 	defer g.appMu.Unlock()
+	previous := g.apps
 	g.apps = make(map[string]*resource.App)
 	for _, info := range containers {
 		appID, ok := info.Properties[propertiesAppIDKey]
@@ -88,6 +152,9 @@ func (g *cfGardenObserver) SyncApps() error {
 		if err != nil {
 			return fmt.Errorf("error fetching application: %w", err)
 		}
+		if prev, ok := previous[appID]; ok && prev.State != app.State {
+			g.logger.Info("application state changed", zap.String("app_id", appID), zap.String("from", prev.State), zap.String("to", app.State))
+		}
 		g.apps[appID] = app
 	}
 
@@ -116,20 +183,78 @@ func (g *cfGardenObserver) App(info garden.ContainerInfo) (*resource.App, error)
 	return app, nil
 }
 
+func (g *cfGardenObserver) SyncProcessStats() error {
+	g.containerMu.RLock()
+	containers := g.containers
+	g.containerMu.RUnlock()
+
+	g.processMu.Lock()
+	defer g.processMu.Unlock()
+	g.processStats = make(map[string]*resource.ProcessStats)
+	for _, info := range containers {
+		processID, err := containerProcessID(info)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := g.processStats[processID]; ok {
+			continue
+		}
+
+		stats, err := g.cf.Processes.GetStats(context.Background(), processID)
+		if err != nil {
+			return fmt.Errorf("error fetching process stats: %w", err)
+		}
+		g.processStats[processID] = stats
+	}
+
+	return nil
+}
+
+func (g *cfGardenObserver) ProcessStats(info garden.ContainerInfo) (*resource.ProcessStats, error) {
+	processID, err := containerProcessID(info)
+	if err != nil {
+		return nil, err
+	}
+
+	g.processMu.Lock()
+	defer g.processMu.Unlock()
+	stats, ok := g.processStats[processID]
+	if ok {
+		return stats, nil
+	}
+
+	stats, err = g.cf.Processes.GetStats(context.Background(), processID)
+	if err != nil {
+		return nil, err
+	}
+	g.processStats[processID] = stats
+
+	return stats, nil
+}
+
 func (g *cfGardenObserver) Start(_ context.Context, _ component.Host) error {
 	g.garden = gardenClient.New(gardenConnection.New("unix", g.config.Garden.Endpoint))
 
 	var err error
-	g.cf, err = newCfClient(g.config.CloudFoundry)
-	if err != nil {
-		return err
+	if g.config.usesCloudFoundry() {
+		g.cf, err = newCfClient(g.config.CloudFoundry)
+		if err != nil {
+			return err
+		}
 	}
 
-	if g.config.IncludeAppLabels {
+	if g.config.DebugEndpoint != "" {
+		if err = g.startDebugServer(); err != nil {
+			return fmt.Errorf("error starting debug server: %w", err)
+		}
+	}
+
+	if g.config.usesCloudFoundry() {
 		g.once.Do(
 			func() {
 				go func() {
-					cacheRefreshTicker := time.NewTicker(g.config.CacheSyncInterval)
+					cacheRefreshTicker := time.NewTicker(g.cacheSyncInterval)
 					defer cacheRefreshTicker.Stop()
 
 					for {
@@ -137,9 +262,17 @@ func (g *cfGardenObserver) Start(_ context.Context, _ component.Host) error {
 						case <-g.doneChan:
 							return
 						case <-cacheRefreshTicker.C:
-							err = g.SyncApps()
-							if err != nil {
-								g.logger.Error("could not sync app cache", zap.Error(err))
+							if g.config.IncludeAppLabels || g.config.ExcludeStoppedApps {
+								if syncErr := g.SyncApps(); syncErr != nil {
+									g.logger.Error("could not sync app cache", zap.Error(syncErr))
+									g.debug.setSyncError(syncSourceCloudFoundry, syncErr)
+								}
+							}
+							if g.config.DiscoverExtraPorts {
+								if syncErr := g.SyncProcessStats(); syncErr != nil {
+									g.logger.Error("could not sync process stats cache", zap.Error(syncErr))
+									g.debug.setSyncError(syncSourceCloudFoundry, syncErr)
+								}
 							}
 						}
 					}
@@ -151,8 +284,12 @@ func (g *cfGardenObserver) Start(_ context.Context, _ component.Host) error {
 	return nil
 }
 
-func (g *cfGardenObserver) Shutdown(_ context.Context) error {
+func (g *cfGardenObserver) Shutdown(ctx context.Context) error {
+	g.StopListAndWatch()
 	close(g.doneChan)
+	if g.debugServer != nil {
+		return g.debugServer.Shutdown(ctx)
+	}
 	return nil
 }
 
@@ -162,14 +299,22 @@ func (g *cfGardenObserver) ListEndpoints() []observer.Endpoint {
 	containers, err := g.garden.Containers(garden.Properties{})
 	if err != nil {
 		g.logger.Error("could not list containers", zap.Error(err))
+		g.debug.setSyncError(syncSourceGarden, err)
 		return endpoints
 	}
 
 	infos := make(map[string]garden.ContainerInfo)
+	observations := make(map[string]int)
+	active := make(map[string][]observer.Endpoint)
 	for _, c := range containers {
+		if !g.handleObserved(c.Handle()) {
+			continue
+		}
+
 		info, err := c.Info()
 		if err != nil {
 			g.logger.Error("error getting container info", zap.String("handle", c.Handle()), zap.Error(err))
+			g.debug.setSyncError(syncSourceGarden, err)
 			continue
 		}
 
@@ -177,14 +322,110 @@ func (g *cfGardenObserver) ListEndpoints() []observer.Endpoint {
 			continue
 		}
 
-		endpoints = append(endpoints, g.containerEndpoints(c.Handle(), info)...)
 		infos[c.Handle()] = info
+		if !g.observe(c.Handle(), observations) {
+			g.logger.Debug("skipping container which is not yet stable", zap.String("handle", c.Handle()))
+			continue
+		}
+		containerEndpoints := g.containerEndpoints(c.Handle(), info)
+		if len(containerEndpoints) > 0 {
+			active[c.Handle()] = containerEndpoints
+		}
+		endpoints = append(endpoints, containerEndpoints...)
+	}
+
+	g.observationMu.Lock()
+	g.observations = observations
+	g.observationMu.Unlock()
+
+	if g.config.IncludeRecentlyStopped > 0 {
+		endpoints = append(endpoints, g.retainStopped(infos, active, endpoints)...)
 	}
 
 	go g.updateContainerCache(infos)
+	g.debug.setEndpoints(endpoints)
 	return endpoints
 }
 
+// observe records that the container with the given handle is listed and returns
+// whether it has been listed in at least MinStableObservations consecutive refreshes
+func (g *cfGardenObserver) observe(handle string, observations map[string]int) bool {
+	g.observationMu.Lock()
+	count := g.observations[handle] + 1
+	g.observationMu.Unlock()
+
+	observations[handle] = count
+	return count >= g.config.MinStableObservations
+}
+
+// retainStopped records the endpoints of the active containers and returns the endpoints of
+// containers which stopped less than IncludeRecentlyStopped ago, flagged with the `state: stopped`
+// label, so receivers can finish collecting from them. A container is stopped once Garden no
+// longer lists it as active, containers which are still active but have no endpoints, e.g. as
+// their application could not be fetched, are not retained. Endpoints whose ID is taken by an
+// active endpoint, e.g. of an instance restarted in a new container with stable IDs, are not returned.
+func (g *cfGardenObserver) retainStopped(infos map[string]garden.ContainerInfo, active map[string][]observer.Endpoint, activeEndpoints []observer.Endpoint) []observer.Endpoint {
+	now := g.now()
+
+	g.retainMu.Lock()
+	defer g.retainMu.Unlock()
+	for handle, endpoints := range g.active {
+		if _, ok := infos[handle]; !ok {
+			g.logger.Debug("retaining endpoints of stopped container", zap.String("handle", handle), zap.Duration("grace_period", g.config.IncludeRecentlyStopped))
+			g.stopped[handle] = stoppedContainer{endpoints: stoppedEndpoints(endpoints), since: now}
+		}
+	}
+	g.active = active
+
+	activeIDs := make(map[observer.EndpointID]struct{}, len(activeEndpoints))
+	for _, e := range activeEndpoints {
+		activeIDs[e.ID] = struct{}{}
+	}
+
+	var endpoints []observer.Endpoint
+	for handle, c := range g.stopped {
+		if _, ok := infos[handle]; ok || now.Sub(c.since) >= g.config.IncludeRecentlyStopped {
+			delete(g.stopped, handle)
+			continue
+		}
+		for _, e := range c.endpoints {
+			if _, ok := activeIDs[e.ID]; !ok {
+				endpoints = append(endpoints, e)
+			}
+		}
+	}
+	return endpoints
+}
+
+// stoppedEndpoints returns copies of the container endpoints labeled with the stopped state
+func stoppedEndpoints(endpoints []observer.Endpoint) []observer.Endpoint {
+	stopped := make([]observer.Endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		details, ok := e.Details.(*observer.Container)
+		if !ok {
+			continue
+		}
+		container := *details
+		container.Labels = make(map[string]string, len(details.Labels)+1)
+		for k, v := range details.Labels {
+			container.Labels[k] = v
+		}
+		container.Labels[labelState] = containerStateStopped
+		e.Details = &container
+		stopped = append(stopped, e)
+	}
+	return stopped
+}
+
+// handleObserved returns whether the container with the given handle
+// passes the include and exclude handle patterns
+func (g *cfGardenObserver) handleObserved(handle string) bool {
+	if len(g.includeHandles) > 0 && !slices.ContainsFunc(g.includeHandles, func(re *regexp.Regexp) bool { return re.MatchString(handle) }) {
+		return false
+	}
+	return !slices.ContainsFunc(g.excludeHandles, func(re *regexp.Regexp) bool { return re.MatchString(handle) })
+}
+
 // containerEndpoints generates a list of observer.Endpoint for a container,
 // this is because a container might have more than one exposed ports
 func (g *cfGardenObserver) containerEndpoints(handle string, info garden.ContainerInfo) []observer.Endpoint {
@@ -194,14 +435,36 @@ func (g *cfGardenObserver) containerEndpoints(handle string, info garden.Contain
 		return nil
 	}
 	ports := strings.Split(portsProp, ",")
+	if g.config.DiscoverExtraPorts {
+		ports = g.mergeExtraPorts(info, ports)
+	}
 
 	var app *resource.App
 	var err error
-	if g.config.IncludeAppLabels {
+	if g.config.IncludeAppLabels || g.config.ExcludeStoppedApps {
 		app, err = g.App(info)
 		if err != nil {
 			g.logger.Error("error fetching application", zap.Error(err))
-			return nil
+			g.debug.setSyncError(syncSourceCloudFoundry, err)
+			if g.config.IncludeAppLabels {
+				return nil
+			}
+		}
+	}
+	// When the application state is unknown the container is kept, so a
+	// CloudFoundry API outage does not remove the endpoints of running apps.
+	if g.config.ExcludeStoppedApps && app != nil && app.State == appStateStopped {
+		g.logger.Debug("skipping container of stopped application", zap.String("handle", handle))
+		return nil
+	}
+	if !g.config.IncludeAppLabels {
+		app = nil
+	}
+
+	idPrefix := handle
+	if g.config.StableEndpointIDs {
+		if id, ok := instanceID(info); ok {
+			idPrefix = id
 		}
 	}
 
@@ -223,8 +486,17 @@ func (g *cfGardenObserver) containerEndpoints(handle string, info garden.Contain
 			Labels:      g.containerLabels(info, app),
 		}
 
+		hostPort := mappedHostPort(info, uint16(port))
+		if g.config.UseHostBindings && hostPort != 0 && info.ExternalIP != "" {
+			details.Host = info.ExternalIP
+			details.Port = hostPort
+			details.AlternatePort = uint16(port)
+		} else {
+			details.AlternatePort = hostPort
+		}
+
 		endpoint := observer.Endpoint{
-			ID:      observer.EndpointID(fmt.Sprintf("%s:%d", details.ContainerID, details.Port)),
+			ID:      observer.EndpointID(fmt.Sprintf("%s:%d", idPrefix, port)),
 			Target:  fmt.Sprintf("%s:%d", details.Host, details.Port),
 			Details: details,
 		}
@@ -243,6 +515,9 @@ func (g *cfGardenObserver) containerLabels(info garden.ContainerInfo, app *resou
 	for k, v := range tags {
 		labels[k] = v
 	}
+	if index, ok := instanceIndex(info, tags); ok {
+		labels[labelInstanceIndex] = strconv.FormatUint(index, 10)
+	}
 
 	if app != nil {
 		for k, v := range app.Metadata.Labels {
@@ -250,9 +525,77 @@ func (g *cfGardenObserver) containerLabels(info garden.ContainerInfo, app *resou
 		}
 	}
 
+	if info.ContainerIP != "" {
+		labels[labelContainerIP] = info.ContainerIP
+	}
+	if info.ExternalIP != "" {
+		labels[labelHostIP] = info.ExternalIP
+	}
+
 	return labels
 }
 
+// mergeExtraPorts adds the ports reported by the CloudFoundry process stats
+// for the container instance which are not already part of the given ports
+func (g *cfGardenObserver) mergeExtraPorts(info garden.ContainerInfo, ports []string) []string {
+	stats, err := g.ProcessStats(info)
+	if err != nil {
+		g.logger.Warn("could not fetch process stats to discover extra ports", zap.Error(err))
+		g.debug.setSyncError(syncSourceCloudFoundry, err)
+		return ports
+	}
+
+	tags, err := parseTags(info)
+	if err != nil {
+		return ports
+	}
+	index, ok := instanceIndex(info, tags)
+	if !ok {
+		return ports
+	}
+
+	for _, stat := range stats.Stats {
+		if stat.Index != int(index) {
+			continue
+		}
+		for _, instancePort := range stat.InstancePorts {
+			internal, ok := instancePort[processStatsInternalPortKey]
+			if !ok || internal <= 0 {
+				continue
+			}
+			port := strconv.Itoa(internal)
+			if !slices.Contains(ports, port) {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// containerProcessID returns the GUID of the CloudFoundry process running in the container
+func containerProcessID(info garden.ContainerInfo) (string, error) {
+	tags, err := parseTags(info)
+	if err != nil {
+		return "", err
+	}
+	processID, ok := tags[tagsProcessIDKey]
+	if !ok {
+		return "", fmt.Errorf("container tags do not have a `%s` field, required to fetch process stats", tagsProcessIDKey)
+	}
+	return processID, nil
+}
+
+// mappedHostPort returns the port on the cell which is mapped to the given
+// container port, or 0 if the port is not mapped
+func mappedHostPort(info garden.ContainerInfo, containerPort uint16) uint16 {
+	for _, m := range info.MappedPorts {
+		if m.ContainerPort == uint32(containerPort) {
+			return uint16(m.HostPort)
+		}
+	}
+	return 0
+}
+
 // The info.Properties contains a key called "log_config", which
 // has contents that look like the following JSON encoded string:
 //
@@ -303,6 +646,58 @@ func parseTags(info garden.ContainerInfo) (map[string]string, error) {
 	return result, nil
 }
 
+// instanceIndex returns the index of the application instance running in the
+// container. It is taken from the `instance_id` tag, falling back to the
+// `index` field of the log_config property.
+func instanceIndex(info garden.ContainerInfo, tags map[string]string) (uint64, bool) {
+	if id, ok := tags[tagsInstanceIDKey]; ok {
+		if index, err := strconv.ParseUint(id, 10, 32); err == nil {
+			return index, true
+		}
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(info.Properties[propertiesLogConfigKey]), &data); err != nil {
+		return 0, false
+	}
+	index, ok := data[logConfigIndexKey].(float64)
+	if !ok || index < 0 || index != float64(uint64(index)) {
+		return 0, false
+	}
+	return uint64(index), true
+}
+
+// instanceID returns an identifier of the application instance running in the
+// container in the form `<process_id>/<index>`, which unlike the container handle
+// remains the same when the instance is restarted in a new container.
+func instanceID(info garden.ContainerInfo) (string, bool) {
+	tags, err := parseTags(info)
+	if err != nil {
+		return "", false
+	}
+	index, ok := instanceIndex(info, tags)
+	if !ok {
+		return "", false
+	}
+
+	processID := tags[tagsProcessIDKey]
+	if processID == "" {
+		processID = tags[tagsAppIDKey]
+	}
+	if processID == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%d", processID, index), true
+}
+
+// jitter extends the interval by a random duration of up to factor times the interval
+func jitter(interval time.Duration, factor float64) time.Duration {
+	if factor <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Float64()*factor*float64(interval))
+}
+
 func newCfClient(cfConfig CfConfig) (*client.Client, error) {
 	var cfg *config.Config
 	var err error
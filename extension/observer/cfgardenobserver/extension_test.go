@@ -4,13 +4,18 @@
 package cfgardenobserver
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/gardenfakes"
 	"github.com/cloudfoundry/go-cfclient/v3/resource"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
 	"go.uber.org/zap"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/observer"
@@ -61,8 +66,10 @@ func TestContainerEndpoints(t *testing.T) {
 						Port:        uint16(8080),
 						Transport:   observer.ProtocolTCP,
 						Labels: map[string]string{
-							"app_id":   appID,
-							"app_name": "myapp",
+							"app_id":         appID,
+							"app_name":       "myapp",
+							"container_ip":   ip,
+							"instance_index": "0",
 						},
 					},
 				},
@@ -89,8 +96,10 @@ func TestContainerEndpoints(t *testing.T) {
 						Port:        uint16(8080),
 						Transport:   observer.ProtocolTCP,
 						Labels: map[string]string{
-							"app_id":   appID,
-							"app_name": "myapp",
+							"app_id":         appID,
+							"app_name":       "myapp",
+							"container_ip":   ip,
+							"instance_index": "0",
 						},
 					},
 				},
@@ -104,8 +113,10 @@ func TestContainerEndpoints(t *testing.T) {
 						Port:        uint16(9999),
 						Transport:   observer.ProtocolTCP,
 						Labels: map[string]string{
-							"app_id":   appID,
-							"app_name": "myapp",
+							"app_id":         appID,
+							"app_name":       "myapp",
+							"container_ip":   ip,
+							"instance_index": "0",
 						},
 					},
 				},
@@ -125,6 +136,120 @@ func TestContainerEndpoints(t *testing.T) {
 	}
 }
 
+func TestContainerEndpointsHostBindings(t *testing.T) {
+	handle := "14d91d46-6ebd-43a1-8e20-316d8e6a92a4"
+	ip := "1.2.3.4"
+	cellIP := "10.0.0.1"
+	logConfig := fmt.Sprintf(`
+{
+    "guid": "%s",
+    "index": 0,
+    "source_name": "CELL",
+    "tags": {
+        "app_name": "myapp"
+    }
+}
+            `, handle)
+	input := garden.ContainerInfo{
+		ContainerIP: ip,
+		ExternalIP:  cellIP,
+		MappedPorts: []garden.PortMapping{
+			{HostPort: 61001, ContainerPort: 8080},
+		},
+		Properties: map[string]string{
+			"log_config":    logConfig,
+			"network.ports": "8080,9999",
+		},
+	}
+	labels := map[string]string{
+		"app_name":       "myapp",
+		"container_ip":   ip,
+		"host_ip":        cellIP,
+		"instance_index": "0",
+	}
+
+	tests := []struct {
+		name            string
+		useHostBindings bool
+		expected        []observer.Endpoint
+	}{
+		{
+			name: "container network",
+			expected: []observer.Endpoint{
+				{
+					ID:     observer.EndpointID(fmt.Sprintf("%s:%d", handle, 8080)),
+					Target: fmt.Sprintf("%s:%d", ip, 8080),
+					Details: &observer.Container{
+						Name:          handle,
+						ContainerID:   handle,
+						Host:          ip,
+						Port:          uint16(8080),
+						AlternatePort: uint16(61001),
+						Transport:     observer.ProtocolTCP,
+						Labels:        labels,
+					},
+				},
+				{
+					ID:     observer.EndpointID(fmt.Sprintf("%s:%d", handle, 9999)),
+					Target: fmt.Sprintf("%s:%d", ip, 9999),
+					Details: &observer.Container{
+						Name:        handle,
+						ContainerID: handle,
+						Host:        ip,
+						Port:        uint16(9999),
+						Transport:   observer.ProtocolTCP,
+						Labels:      labels,
+					},
+				},
+			},
+		},
+		{
+			name:            "host bindings",
+			useHostBindings: true,
+			expected: []observer.Endpoint{
+				{
+					ID:     observer.EndpointID(fmt.Sprintf("%s:%d", handle, 8080)),
+					Target: fmt.Sprintf("%s:%d", cellIP, 61001),
+					Details: &observer.Container{
+						Name:          handle,
+						ContainerID:   handle,
+						Host:          cellIP,
+						Port:          uint16(61001),
+						AlternatePort: uint16(8080),
+						Transport:     observer.ProtocolTCP,
+						Labels:        labels,
+					},
+				},
+				{
+					ID:     observer.EndpointID(fmt.Sprintf("%s:%d", handle, 9999)),
+					Target: fmt.Sprintf("%s:%d", ip, 9999),
+					Details: &observer.Container{
+						Name:        handle,
+						ContainerID: handle,
+						Host:        ip,
+						Port:        uint16(9999),
+						Transport:   observer.ProtocolTCP,
+						Labels:      labels,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := loadConfig(t, component.NewID(metadata.Type))
+			config.UseHostBindings = tt.useHostBindings
+			ext, err := newObserver(config, zap.NewNop())
+			require.NoError(t, err)
+
+			obs, ok := ext.(*cfGardenObserver)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, obs.containerEndpoints(handle, input))
+		})
+	}
+}
+
 func TestIncludeAppLabels(t *testing.T) {
 	handle := "14d91d46-6ebd-43a1-8e20-316d8e6a92a4"
 	ip := "1.2.3.4"
@@ -159,10 +284,12 @@ func TestIncludeAppLabels(t *testing.T) {
 				Port:        uint16(8080),
 				Transport:   observer.ProtocolTCP,
 				Labels: map[string]string{
-					"app_id":     appID,
-					"app_name":   "myapp",
-					"app_label":  "app_value",
-					"app_label2": "app_value2",
+					"app_id":         appID,
+					"app_name":       "myapp",
+					"app_label":      "app_value",
+					"app_label2":     "app_value2",
+					"container_ip":   ip,
+					"instance_index": "0",
 				},
 			},
 		},
@@ -231,6 +358,7 @@ func TestContainerLabels(t *testing.T) {
 		"source_id":           "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
 		"space_id":            "99999999-8888-7777-6666-555555555555",
 		"space_name":          "example-space",
+		"instance_index":      "0",
 		"key":                 "value",
 		"key2":                "value2",
 	}
@@ -245,3 +373,416 @@ func TestContainerLabels(t *testing.T) {
 
 	require.Equal(t, expected, obs.containerLabels(info, app))
 }
+
+func TestInstanceIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		logConfig string
+		tags      map[string]string
+		index     uint64
+		ok        bool
+	}{
+		{
+			name:  "from instance_id tag",
+			tags:  map[string]string{"instance_id": "3"},
+			index: 3,
+			ok:    true,
+		},
+		{
+			name:      "from log_config index",
+			logConfig: `{"index": 2, "tags": {}}`,
+			tags:      map[string]string{},
+			index:     2,
+			ok:        true,
+		},
+		{
+			name:      "invalid instance_id tag falls back to log_config index",
+			logConfig: `{"index": 1, "tags": {"instance_id": "abc"}}`,
+			tags:      map[string]string{"instance_id": "abc"},
+			index:     1,
+			ok:        true,
+		},
+		{
+			name:      "negative index",
+			logConfig: `{"index": -1, "tags": {}}`,
+			tags:      map[string]string{},
+		},
+		{
+			name:      "missing index",
+			logConfig: `{"tags": {}}`,
+			tags:      map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := garden.ContainerInfo{
+				Properties: map[string]string{
+					"log_config": tt.logConfig,
+				},
+			}
+			index, ok := instanceIndex(info, tt.tags)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.index, index)
+		})
+	}
+}
+
+func TestStableEndpointIDs(t *testing.T) {
+	processID := "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+	newInfo := func(index int) garden.ContainerInfo {
+		return garden.ContainerInfo{
+			ContainerIP: "1.2.3.4",
+			Properties: map[string]string{
+				"log_config": fmt.Sprintf(`
+{
+    "index": %[1]d,
+    "tags": {
+        "app_id": "11111111-2222-3333-4444-555555555555",
+        "instance_id": "%[1]d",
+        "process_id": "%[2]s"
+    }
+}
+            `, index, processID),
+				"network.ports": "8080",
+			},
+		}
+	}
+
+	config := loadConfig(t, component.NewID(metadata.Type))
+	config.StableEndpointIDs = true
+	ext, err := newObserver(config, zap.NewNop())
+	require.NoError(t, err)
+	obs, ok := ext.(*cfGardenObserver)
+	require.True(t, ok)
+
+	endpoints := obs.containerEndpoints("handle-1", newInfo(0))
+	require.Len(t, endpoints, 1)
+	require.Equal(t, observer.EndpointID(processID+"/0:8080"), endpoints[0].ID)
+
+	// a recreated container for the same instance keeps the endpoint ID
+	endpoints = obs.containerEndpoints("handle-2", newInfo(0))
+	require.Len(t, endpoints, 1)
+	require.Equal(t, observer.EndpointID(processID+"/0:8080"), endpoints[0].ID)
+	require.Equal(t, "handle-2", endpoints[0].Details.(*observer.Container).ContainerID)
+
+	endpoints = obs.containerEndpoints("handle-3", newInfo(1))
+	require.Len(t, endpoints, 1)
+	require.Equal(t, observer.EndpointID(processID+"/1:8080"), endpoints[0].ID)
+}
+
+func TestDiscoverExtraPorts(t *testing.T) {
+	handle := "14d91d46-6ebd-43a1-8e20-316d8e6a92a4"
+	processID := "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+	input := garden.ContainerInfo{
+		ContainerIP: "1.2.3.4",
+		Properties: map[string]string{
+			"log_config": fmt.Sprintf(`
+{
+    "index": 1,
+    "tags": {
+        "instance_id": "1",
+        "process_id": "%s"
+    }
+}
+            `, processID),
+			"network.ports": "8080",
+		},
+	}
+
+	config := loadConfig(t, component.NewID(metadata.Type))
+	config.DiscoverExtraPorts = true
+	ext, err := newObserver(config, zap.NewNop())
+	require.NoError(t, err)
+	obs, ok := ext.(*cfGardenObserver)
+	require.True(t, ok)
+	obs.processStats[processID] = &resource.ProcessStats{
+		Stats: []resource.ProcessStat{
+			{
+				Index: 0,
+				InstancePorts: []map[string]int{
+					{"external": 61000, "internal": 9000},
+				},
+			},
+			{
+				Index: 1,
+				InstancePorts: []map[string]int{
+					{"external": 61001, "internal": 8080},
+					{"external": 61002, "internal": 9090},
+				},
+			},
+		},
+	}
+
+	endpoints := obs.containerEndpoints(handle, input)
+	require.Len(t, endpoints, 2)
+	require.Equal(t, observer.EndpointID(handle+":8080"), endpoints[0].ID)
+	require.Equal(t, observer.EndpointID(handle+":9090"), endpoints[1].ID)
+	require.Equal(t, "1.2.3.4:9090", endpoints[1].Target)
+}
+
+func TestExcludeStoppedApps(t *testing.T) {
+	handle := "14d91d46-6ebd-43a1-8e20-316d8e6a92a4"
+	appID := "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+	input := garden.ContainerInfo{
+		ContainerIP: "1.2.3.4",
+		Properties: map[string]string{
+			"log_config": fmt.Sprintf(`
+{
+    "index": 0,
+    "tags": {
+        "app_id": "%s"
+    }
+}
+            `, appID),
+			"network.ports":  "8080",
+			"network.app_id": appID,
+		},
+	}
+
+	config := loadConfig(t, component.NewID(metadata.Type))
+	config.ExcludeStoppedApps = true
+	ext, err := newObserver(config, zap.NewNop())
+	require.NoError(t, err)
+	obs, ok := ext.(*cfGardenObserver)
+	require.True(t, ok)
+
+	obs.apps[appID] = &resource.App{
+		State: "STARTED",
+		Metadata: &resource.Metadata{
+			Labels: map[string]*string{"app_label": strPtr("app_value")},
+		},
+	}
+	endpoints := obs.containerEndpoints(handle, input)
+	require.Len(t, endpoints, 1)
+	// app labels are only added when include_app_labels is set
+	require.NotContains(t, endpoints[0].Details.(*observer.Container).Labels, "app_label")
+
+	obs.apps[appID].State = "STOPPED"
+	require.Empty(t, obs.containerEndpoints(handle, input))
+
+	// containers are kept when the application state cannot be determined
+	delete(input.Properties, "network.app_id")
+	require.Len(t, obs.containerEndpoints(handle, input), 1)
+}
+
+func TestMinStableObservations(t *testing.T) {
+	newContainer := func(handle string) garden.Container {
+		c := &gardenfakes.FakeContainer{}
+		c.HandleReturns(handle)
+		c.InfoReturns(garden.ContainerInfo{
+			State:       "active",
+			ContainerIP: "1.2.3.4",
+			Properties: map[string]string{
+				"log_config":    `{"index": 0, "tags": {}}`,
+				"network.ports": "8080",
+			},
+		}, nil)
+		return c
+	}
+	first := newContainer("first")
+	second := newContainer("second")
+	client := &gardenfakes.FakeClient{}
+
+	config := loadConfig(t, component.NewID(metadata.Type))
+	config.MinStableObservations = 2
+	ext, err := newObserver(config, zap.NewNop())
+	require.NoError(t, err)
+	obs, ok := ext.(*cfGardenObserver)
+	require.True(t, ok)
+	obs.garden = client
+
+	client.ContainersReturns([]garden.Container{first}, nil)
+	require.Empty(t, obs.ListEndpoints())
+
+	client.ContainersReturns([]garden.Container{first, second}, nil)
+	endpoints := obs.ListEndpoints()
+	require.Len(t, endpoints, 1)
+	require.Equal(t, observer.EndpointID("first:8080"), endpoints[0].ID)
+
+	// a container which disappears from the listing has to be observed again
+	client.ContainersReturns([]garden.Container{first}, nil)
+	require.Len(t, obs.ListEndpoints(), 1)
+	client.ContainersReturns([]garden.Container{first, second}, nil)
+	require.Len(t, obs.ListEndpoints(), 1)
+	require.Len(t, obs.ListEndpoints(), 2)
+}
+
+func TestIncludeRecentlyStopped(t *testing.T) {
+	newContainer := func(handle string) garden.Container {
+		c := &gardenfakes.FakeContainer{}
+		c.HandleReturns(handle)
+		c.InfoReturns(garden.ContainerInfo{
+			State:       "active",
+			ContainerIP: "1.2.3.4",
+			Properties: map[string]string{
+				"log_config":    `{"index": 0, "tags": {}}`,
+				"network.ports": "8080",
+			},
+		}, nil)
+		return c
+	}
+	first := newContainer("first")
+	second := newContainer("second")
+	client := &gardenfakes.FakeClient{}
+
+	config := loadConfig(t, component.NewID(metadata.Type))
+	config.IncludeRecentlyStopped = time.Minute
+	ext, err := newObserver(config, zap.NewNop())
+	require.NoError(t, err)
+	obs, ok := ext.(*cfGardenObserver)
+	require.True(t, ok)
+	obs.garden = client
+	now := time.Now()
+	obs.now = func() time.Time { return now }
+
+	client.ContainersReturns([]garden.Container{first, second}, nil)
+	require.Len(t, obs.ListEndpoints(), 2)
+
+	// the endpoints of a container which is gone are retained for the grace period
+	client.ContainersReturns([]garden.Container{first}, nil)
+	endpoints := obs.ListEndpoints()
+	require.Len(t, endpoints, 2)
+	require.NotContains(t, endpoints[0].Details.(*observer.Container).Labels, "state")
+	require.Equal(t, observer.EndpointID("second:8080"), endpoints[1].ID)
+	require.Equal(t, "stopped", endpoints[1].Details.(*observer.Container).Labels["state"])
+
+	now = now.Add(30 * time.Second)
+	require.Len(t, obs.ListEndpoints(), 2)
+
+	now = now.Add(30 * time.Second)
+	endpoints = obs.ListEndpoints()
+	require.Len(t, endpoints, 1)
+	require.Equal(t, observer.EndpointID("first:8080"), endpoints[0].ID)
+
+	// a container which is active again is no longer flagged as stopped
+	client.ContainersReturns([]garden.Container{}, nil)
+	require.Len(t, obs.ListEndpoints(), 1)
+	client.ContainersReturns([]garden.Container{first}, nil)
+	endpoints = obs.ListEndpoints()
+	require.Len(t, endpoints, 1)
+	require.NotContains(t, endpoints[0].Details.(*observer.Container).Labels, "state")
+
+	// a container which Garden no longer reports as active is retained
+	first.(*gardenfakes.FakeContainer).InfoReturns(garden.ContainerInfo{State: "stopped"}, nil)
+	endpoints = obs.ListEndpoints()
+	require.Len(t, endpoints, 1)
+	require.Equal(t, "stopped", endpoints[0].Details.(*observer.Container).Labels["state"])
+
+	// a container which is still active but has no endpoints is not retained
+	client.ContainersReturns([]garden.Container{second}, nil)
+	require.Len(t, obs.ListEndpoints(), 2)
+	second.(*gardenfakes.FakeContainer).InfoReturns(garden.ContainerInfo{
+		State:      "active",
+		Properties: map[string]string{"log_config": `{"index": 0, "tags": {}}`},
+	}, nil)
+	endpoints = obs.ListEndpoints()
+	require.Len(t, endpoints, 1)
+	require.Equal(t, observer.EndpointID("first:8080"), endpoints[0].ID)
+}
+
+func TestJitter(t *testing.T) {
+	require.Equal(t, time.Minute, jitter(time.Minute, 0))
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Minute, 0.5)
+		require.GreaterOrEqual(t, d, time.Minute)
+		require.LessOrEqual(t, d, 90*time.Second)
+	}
+
+	config := loadConfig(t, component.NewID(metadata.Type))
+	config.JitterFactor = 0.5
+	ext, err := newObserver(config, zap.NewNop())
+	require.NoError(t, err)
+	obs, ok := ext.(*cfGardenObserver)
+	require.True(t, ok)
+	require.GreaterOrEqual(t, obs.cacheSyncInterval, 5*time.Minute)
+	require.LessOrEqual(t, obs.cacheSyncInterval, 7*time.Minute+30*time.Second)
+}
+
+func TestHandleObserved(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		handle   string
+		observed bool
+	}{
+		{
+			name:     "no patterns",
+			handle:   "executor-healthcheck-1234",
+			observed: true,
+		},
+		{
+			name:     "excluded",
+			exclude:  []string{"^executor-healthcheck-"},
+			handle:   "executor-healthcheck-1234",
+			observed: false,
+		},
+		{
+			name:     "not excluded",
+			exclude:  []string{"^executor-healthcheck-"},
+			handle:   "14d91d46-6ebd-43a1-8e20-316d8e6a92a4",
+			observed: true,
+		},
+		{
+			name:     "not included",
+			include:  []string{"^[0-9a-f-]+$"},
+			handle:   "executor-healthcheck-1234",
+			observed: false,
+		},
+		{
+			name:     "included",
+			include:  []string{"^foo-", "^[0-9a-f-]+$"},
+			handle:   "14d91d46-6ebd-43a1-8e20-316d8e6a92a4",
+			observed: true,
+		},
+		{
+			name:     "included but excluded",
+			include:  []string{"^[0-9a-f-]+$"},
+			exclude:  []string{"^14d91d46"},
+			handle:   "14d91d46-6ebd-43a1-8e20-316d8e6a92a4",
+			observed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := loadConfig(t, component.NewID(metadata.Type))
+			config.IncludeHandles = tt.include
+			config.ExcludeHandles = tt.exclude
+			ext, err := newObserver(config, zap.NewNop())
+			require.NoError(t, err)
+
+			obs, ok := ext.(*cfGardenObserver)
+			require.True(t, ok)
+			require.Equal(t, tt.observed, obs.handleObserved(tt.handle))
+		})
+	}
+}
+
+type nopNotifier struct{}
+
+func (nopNotifier) ID() observer.NotifyID { return "nopNotifier" }
+
+func (nopNotifier) OnAdd([]observer.Endpoint) {}
+
+func (nopNotifier) OnRemove([]observer.Endpoint) {}
+
+func (nopNotifier) OnChange([]observer.Endpoint) {}
+
+func TestStartShutdown(t *testing.T) {
+	config := loadConfig(t, component.NewID(metadata.Type))
+	config.Garden.Endpoint = filepath.Join(t.TempDir(), "garden.sock")
+	ext, err := newObserver(config, zap.NewNop())
+	require.NoError(t, err)
+	obs, ok := ext.(*cfGardenObserver)
+	require.True(t, ok)
+
+	// the CloudFoundry API is not required when no feature uses it
+	require.NoError(t, ext.Start(context.Background(), componenttest.NewNopHost()))
+	require.Nil(t, obs.cf)
+
+	// watching endpoints stops on shutdown, goleak reports the watcher otherwise
+	obs.ListAndWatch(nopNotifier{})
+	require.NoError(t, ext.Shutdown(context.Background()))
+}
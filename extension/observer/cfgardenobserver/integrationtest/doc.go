@@ -0,0 +1,5 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// integrationtest contains integration tests for the CF Garden observer
+package integrationtest // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/observer/cfgardenobserver/integrationtest"
@@ -0,0 +1,223 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package integrationtest // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/observer/cfgardenobserver/integrationtest"
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/gardenfakes"
+	"code.cloudfoundry.org/garden/server"
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/observer/cfgardenobserver"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/receivercreator"
+)
+
+var dummyType = component.MustNewType("dummy")
+
+type dummyConfig struct {
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// runningReceivers keeps track of the endpoints of the started dummy receivers.
+type runningReceivers struct {
+	mu        sync.Mutex
+	endpoints map[string]int
+}
+
+func (r *runningReceivers) snapshot() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.endpoints)
+}
+
+type dummyReceiver struct {
+	endpoint string
+	running  *runningReceivers
+}
+
+func (d *dummyReceiver) Start(context.Context, component.Host) error {
+	d.running.mu.Lock()
+	defer d.running.mu.Unlock()
+	d.running.endpoints[d.endpoint]++
+	return nil
+}
+
+func (d *dummyReceiver) Shutdown(context.Context) error {
+	d.running.mu.Lock()
+	defer d.running.mu.Unlock()
+	d.running.endpoints[d.endpoint]--
+	if d.running.endpoints[d.endpoint] == 0 {
+		delete(d.running.endpoints, d.endpoint)
+	}
+	return nil
+}
+
+func newDummyFactory(running *runningReceivers) receiver.Factory {
+	return receiver.NewFactory(
+		dummyType,
+		func() component.Config { return &dummyConfig{} },
+		receiver.WithMetrics(func(_ context.Context, _ receiver.Settings, cfg component.Config, _ consumer.Metrics) (receiver.Metrics, error) {
+			return &dummyReceiver{endpoint: cfg.(*dummyConfig).Endpoint, running: running}, nil
+		}, component.StabilityLevelDevelopment),
+	)
+}
+
+type receiverCreatorHost struct {
+	component.Host
+	factories  map[component.Type]receiver.Factory
+	extensions map[component.ID]component.Component
+}
+
+func (h *receiverCreatorHost) GetFactory(kind component.Kind, componentType component.Type) component.Factory {
+	if kind != component.KindReceiver {
+		return nil
+	}
+	return h.factories[componentType]
+}
+
+func (h *receiverCreatorHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+// gardenBackend serves the given containers through a fake Garden backend.
+type gardenBackend struct {
+	*gardenfakes.FakeBackend
+	mu         sync.Mutex
+	containers map[string]garden.ContainerInfo
+}
+
+func newGardenBackend() *gardenBackend {
+	b := &gardenBackend{
+		FakeBackend: &gardenfakes.FakeBackend{},
+		containers:  make(map[string]garden.ContainerInfo),
+	}
+	b.ContainersStub = func(garden.Properties) ([]garden.Container, error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		var containers []garden.Container
+		for handle, info := range b.containers {
+			containers = append(containers, newFakeContainer(handle, info))
+		}
+		return containers, nil
+	}
+	b.LookupStub = func(handle string) (garden.Container, error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		info, ok := b.containers[handle]
+		if !ok {
+			return nil, garden.ContainerNotFoundError{Handle: handle}
+		}
+		return newFakeContainer(handle, info), nil
+	}
+	return b
+}
+
+func (b *gardenBackend) set(handle string, info garden.ContainerInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.containers[handle] = info
+}
+
+func (b *gardenBackend) remove(handle string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.containers, handle)
+}
+
+func newFakeContainer(handle string, info garden.ContainerInfo) garden.Container {
+	c := &gardenfakes.FakeContainer{}
+	c.HandleReturns(handle)
+	c.InfoReturns(info, nil)
+	return c
+}
+
+func containerInfo(ip, appName string) garden.ContainerInfo {
+	return garden.ContainerInfo{
+		State:       "active",
+		ContainerIP: ip,
+		Properties: map[string]string{
+			"log_config":    `{"index": 0, "tags": {"app_name": "` + appName + `"}}`,
+			"network.ports": "8080",
+		},
+	}
+}
+
+func TestReceiverCreatorIntegration(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "garden.sock")
+	backend := newGardenBackend()
+	backend.set("first", containerInfo("10.0.0.1", "myapp"))
+	backend.set("other", containerInfo("10.0.0.2", "otherapp"))
+
+	gardenServer := server.New("unix", socket, 0, time.Second, backend, lager.NewLogger("garden"))
+	require.NoError(t, gardenServer.SetupBomberman())
+	listener, err := gardenServer.Listen()
+	require.NoError(t, err)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- gardenServer.Serve(listener) }()
+	defer func() {
+		require.NoError(t, gardenServer.Stop())
+		require.NoError(t, <-serveErr)
+	}()
+
+	observerFactory := cfgardenobserver.NewFactory()
+	observerCfg := observerFactory.CreateDefaultConfig().(*cfgardenobserver.Config)
+	observerCfg.Garden.Endpoint = socket
+	observerCfg.RefreshInterval = 100 * time.Millisecond
+	ext, err := observerFactory.Create(context.Background(), extensiontest.NewNopSettings(observerFactory.Type()), observerCfg)
+	require.NoError(t, err)
+	require.NoError(t, ext.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() { require.NoError(t, ext.Shutdown(context.Background())) }()
+
+	running := &runningReceivers{endpoints: make(map[string]int)}
+	host := &receiverCreatorHost{
+		Host:       componenttest.NewNopHost(),
+		factories:  map[component.Type]receiver.Factory{dummyType: newDummyFactory(running)},
+		extensions: map[component.ID]component.Component{component.NewID(observerFactory.Type()): ext},
+	}
+
+	rcFactory := receivercreator.NewFactory()
+	rcCfg := rcFactory.CreateDefaultConfig()
+	require.NoError(t, confmap.NewFromStringMap(map[string]any{
+		"watch_observers": []string{observerFactory.Type().String()},
+		"receivers": map[string]any{
+			dummyType.String(): map[string]any{
+				"rule": `type == "container" && labels["app_name"] == "myapp"`,
+			},
+		},
+	}).Unmarshal(rcCfg))
+	rc, err := rcFactory.CreateMetrics(context.Background(), receivertest.NewNopSettings(rcFactory.Type()), rcCfg, consumertest.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, rc.Start(context.Background(), host))
+	defer func() { require.NoError(t, rc.Shutdown(context.Background())) }()
+
+	require.Eventually(t, func() bool {
+		return maps.Equal(map[string]int{"10.0.0.1:8080": 1}, running.snapshot())
+	}, 5*time.Second, 50*time.Millisecond, "receiver not created for the matching container")
+
+	backend.set("second", containerInfo("10.0.0.3", "myapp"))
+	require.Eventually(t, func() bool {
+		return maps.Equal(map[string]int{"10.0.0.1:8080": 1, "10.0.0.3:8080": 1}, running.snapshot())
+	}, 5*time.Second, 50*time.Millisecond, "receiver not created for the new container")
+
+	backend.remove("first")
+	require.Eventually(t, func() bool {
+		return maps.Equal(map[string]int{"10.0.0.3:8080": 1}, running.snapshot())
+	}, 5*time.Second, 50*time.Millisecond, "receiver not destroyed for the removed container")
+}
@@ -0,0 +1,99 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver"
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadata"
+)
+
+// cardinalityTracker counts the distinct stream label sets received during an interval,
+// giving an early signal of runaway label cardinality before it reaches the backend.
+type cardinalityTracker struct {
+	config           CardinalityConfig
+	logger           *zap.Logger
+	telemetryBuilder *metadata.TelemetryBuilder
+
+	mu        sync.Mutex
+	labelSets map[uint64]struct{}
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newCardinalityTracker(config CardinalityConfig, logger *zap.Logger, telemetryBuilder *metadata.TelemetryBuilder) *cardinalityTracker {
+	return &cardinalityTracker{
+		config:           config,
+		logger:           logger,
+		telemetryBuilder: telemetryBuilder,
+		labelSets:        make(map[uint64]struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+func (t *cardinalityTracker) start() {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(t.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-ticker.C:
+				t.flush(context.Background())
+			}
+		}
+	}()
+}
+
+// observe records the label sets of the streams of the push request.
+func (t *cardinalityTracker) observe(pushRequest *push.PushRequest) {
+	hashes := make([]uint64, 0, len(pushRequest.Streams))
+	for _, stream := range pushRequest.Streams {
+		ls, err := promql_parser.ParseMetric(stream.Labels)
+		if err != nil {
+			continue
+		}
+		hashes = append(hashes, ls.Hash())
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, h := range hashes {
+		t.labelSets[h] = struct{}{}
+	}
+}
+
+// flush records the number of label sets seen during the interval, warns
+// when it exceeds the configured threshold and starts a new interval.
+func (t *cardinalityTracker) flush(ctx context.Context) {
+	t.mu.Lock()
+	count := len(t.labelSets)
+	t.labelSets = make(map[uint64]struct{}, count)
+	t.mu.Unlock()
+
+	t.telemetryBuilder.LokiReceiverStreamLabelSets.Record(ctx, int64(count))
+	if t.config.WarnThreshold > 0 && count > t.config.WarnThreshold {
+		t.logger.Warn("number of distinct stream label sets exceeds the threshold",
+			zap.Int("label_sets", count),
+			zap.Int("threshold", t.config.WarnThreshold),
+			zap.Duration("interval", t.config.Interval),
+		)
+	}
+}
+
+func (t *cardinalityTracker) shutdown() {
+	close(t.done)
+	t.wg.Wait()
+}
@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadatatest"
+)
+
+func TestCardinalityTracker(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	defer telemetryBuilder.Shutdown()
+
+	core, logs := observer.New(zap.WarnLevel)
+	tracker := newCardinalityTracker(CardinalityConfig{Enabled: true, WarnThreshold: 2}, zap.New(core), telemetryBuilder)
+
+	tracker.observe(&push.PushRequest{
+		Streams: []push.Stream{
+			{Labels: `{foo="bar"}`},
+			{Labels: `{foo="baz"}`},
+			{Labels: `{foo="bar", job="test"}`},
+			{Labels: `{job="test", foo="bar"}`},
+			{Labels: `{foo=`},
+		},
+	})
+	tracker.observe(&push.PushRequest{
+		Streams: []push.Stream{
+			{Labels: `{foo="bar"}`},
+		},
+	})
+	tracker.flush(context.Background())
+
+	metadatatest.AssertEqualLokiReceiverStreamLabelSets(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 3}},
+		metricdatatest.IgnoreTimestamp())
+	require.Equal(t, 1, logs.FilterMessage("number of distinct stream label sets exceeds the threshold").Len())
+
+	// label sets are counted per interval
+	tracker.observe(&push.PushRequest{
+		Streams: []push.Stream{
+			{Labels: `{foo="bar"}`},
+		},
+	})
+	tracker.flush(context.Background())
+
+	metadatatest.AssertEqualLokiReceiverStreamLabelSets(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
+	require.Equal(t, 1, logs.FilterMessage("number of distinct stream label sets exceeds the threshold").Len())
+}
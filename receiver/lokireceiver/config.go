@@ -5,6 +5,9 @@ package lokireceiver // import "github.com/open-telemetry/opentelemetry-collecto
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configgrpc"
@@ -16,6 +19,8 @@ const (
 	// Protocol values.
 	protoGRPC = "protocols::grpc"
 	protoHTTP = "protocols::http"
+
+	echoKey = "echo"
 )
 
 // Protocols is the configuration for the supported protocols.
@@ -29,8 +34,96 @@ type Config struct {
 	// Protocols is the configuration for the supported protocols, currently gRPC and HTTP (Proto and JSON).
 	Protocols     `mapstructure:"protocols"`
 	KeepTimestamp bool `mapstructure:"use_incoming_timestamp"`
+	// Limits guards the receiver against malformed or abusive push requests.
+	Limits LimitsConfig `mapstructure:"limits"`
+	// Echo, when set, forwards every accepted push request to a secondary Loki compatible
+	// push endpoint, allowing side-by-side validation while migrating from Loki.
+	Echo *confighttp.ClientConfig `mapstructure:"echo"`
+	// PushPaths are the URL paths on which the HTTP server accepts push requests,
+	// useful when the receiver is mounted under a different prefix by a reverse proxy.
+	// Default: ["/loki/api/v1/push"]
+	PushPaths []string `mapstructure:"push_paths"`
+	// TraceContext configures populating the trace context of log records from
+	// the structured metadata of Loki entries.
+	TraceContext TraceContextConfig `mapstructure:"trace_context"`
+	// PropagateW3CHeaders determines whether the W3C `traceparent` and `baggage` headers of HTTP
+	// push requests are applied to all log records of the push, the trace context on the records
+	// and the baggage members as attributes.
+	PropagateW3CHeaders bool `mapstructure:"propagate_w3c_headers"`
+	// DebugSampleRate is the fraction, between 0 and 1, of push requests whose decoded streams
+	// and converted log records are logged at debug level, to troubleshoot the conversion.
+	// Default: 0
+	DebugSampleRate float64 `mapstructure:"debug_sample_rate"`
+	// Cardinality configures tracking the number of distinct stream label sets received per interval.
+	Cardinality CardinalityConfig `mapstructure:"cardinality"`
+	// AckMode determines whether push requests are acknowledged before or after
+	// the next consumer accepted the log records.
+	// Default: after_consume
+	AckMode AckMode `mapstructure:"ack_mode"`
+}
+
+// CardinalityConfig defines how the stream label cardinality is tracked.
+type CardinalityConfig struct {
+	// Enabled determines whether the distinct stream label sets are counted and
+	// reported through the `loki_receiver_stream_label_sets` metric.
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is the period over which distinct stream label sets are counted.
+	// Default: 1m
+	Interval time.Duration `mapstructure:"interval"`
+	// WarnThreshold is the number of distinct stream label sets per interval above
+	// which a warning is logged. A value of 0 disables the warning.
+	WarnThreshold int `mapstructure:"warn_threshold"`
+}
+
+// TraceContextConfig defines which structured metadata keys hold the trace context of an entry.
+type TraceContextConfig struct {
+	// Enabled determines whether the trace and span IDs of log records are set from structured metadata.
+	Enabled bool `mapstructure:"enabled"`
+	// TraceIDKey is the structured metadata key holding the hex encoded trace ID.
+	TraceIDKey string `mapstructure:"trace_id_key"`
+	// SpanIDKey is the structured metadata key holding the hex encoded span ID.
+	SpanIDKey string `mapstructure:"span_id_key"`
+}
+
+// LimitsConfig defines the limits enforced on every push request. A value of 0 disables the limit.
+type LimitsConfig struct {
+	// MaxLabelsPerStream is the maximum number of labels a single stream may have.
+	MaxLabelsPerStream int `mapstructure:"max_labels_per_stream"`
+	// MaxLabelNameLength is the maximum length in bytes of a stream label name.
+	MaxLabelNameLength int `mapstructure:"max_label_name_length"`
+	// MaxLabelValueLength is the maximum length in bytes of a stream label value.
+	MaxLabelValueLength int `mapstructure:"max_label_value_length"`
+	// MaxEntriesPerPush is the maximum number of entries, across all streams, a push request may contain.
+	MaxEntriesPerPush int `mapstructure:"max_entries_per_push"`
+	// MaxLineLength is the maximum length in bytes of an entry line.
+	MaxLineLength int `mapstructure:"max_line_length"`
+	// MaxLineLengthPolicy determines what happens to entries exceeding MaxLineLength,
+	// either truncate them or reject the whole push request.
+	MaxLineLengthPolicy LineLengthPolicy `mapstructure:"max_line_length_policy"`
 }
 
+// LineLengthPolicy describes how entries exceeding the maximum line length are handled
+type LineLengthPolicy string
+
+const (
+	// LineLengthPolicyTruncate truncates the line and flags the log record with the `loki.truncated` attribute
+	LineLengthPolicyTruncate LineLengthPolicy = "truncate"
+	// LineLengthPolicyReject rejects the push request
+	LineLengthPolicyReject LineLengthPolicy = "reject"
+)
+
+// AckMode describes when push requests are acknowledged
+type AckMode string
+
+const (
+	// AckModeImmediate acknowledges the push request once it is converted, the log records
+	// are passed to the next consumer asynchronously and failures are only logged
+	AckModeImmediate AckMode = "immediate"
+	// AckModeAfterConsume acknowledges the push request once the next consumer accepted the
+	// log records, failures are returned to the client so it can retry the push
+	AckModeAfterConsume AckMode = "after_consume"
+)
+
 var (
 	_ component.Config    = (*Config)(nil)
 	_ confmap.Unmarshaler = (*Config)(nil)
@@ -41,11 +134,56 @@ func (cfg *Config) Validate() error {
 	if cfg.GRPC == nil && cfg.HTTP == nil {
 		return errors.New("must specify at least one protocol when using the Loki receiver")
 	}
+	if cfg.Limits.MaxLabelsPerStream < 0 || cfg.Limits.MaxLabelNameLength < 0 ||
+		cfg.Limits.MaxLabelValueLength < 0 || cfg.Limits.MaxEntriesPerPush < 0 || cfg.Limits.MaxLineLength < 0 {
+		return errors.New("limits must not be negative")
+	}
+	switch cfg.Limits.MaxLineLengthPolicy {
+	case LineLengthPolicyTruncate, LineLengthPolicyReject:
+	default:
+		return fmt.Errorf("limits.max_line_length_policy must be one of [%s, %s]. Specified value: %s", LineLengthPolicyTruncate, LineLengthPolicyReject, cfg.Limits.MaxLineLengthPolicy)
+	}
+	switch cfg.AckMode {
+	case AckModeImmediate, AckModeAfterConsume:
+	default:
+		return fmt.Errorf("ack_mode must be one of [%s, %s]. Specified value: %s", AckModeImmediate, AckModeAfterConsume, cfg.AckMode)
+	}
+	if cfg.Echo != nil && cfg.Echo.Endpoint == "" {
+		return errors.New("echo.endpoint must be specified when echo is configured")
+	}
+	if cfg.TraceContext.Enabled && (cfg.TraceContext.TraceIDKey == "" || cfg.TraceContext.SpanIDKey == "") {
+		return errors.New("trace_context.trace_id_key and trace_context.span_id_key must not be empty when trace_context is enabled")
+	}
+	if cfg.DebugSampleRate < 0 || cfg.DebugSampleRate > 1 {
+		return fmt.Errorf("debug_sample_rate must be between 0 and 1. Specified value: %v", cfg.DebugSampleRate)
+	}
+	if cfg.Cardinality.Enabled && cfg.Cardinality.Interval <= 0 {
+		return errors.New("cardinality.interval must be positive when cardinality is enabled")
+	}
+	if cfg.Cardinality.WarnThreshold < 0 {
+		return errors.New("cardinality.warn_threshold must not be negative")
+	}
+	seen := make(map[string]struct{}, len(cfg.PushPaths))
+	for _, path := range cfg.PushPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("push path %q must start with '/'", path)
+		}
+		if _, ok := seen[path]; ok {
+			return fmt.Errorf("push path %q is specified more than once", path)
+		}
+		seen[path] = struct{}{}
+	}
 	return nil
 }
 
 // Unmarshal a confmap.Conf into the config struct.
 func (cfg *Config) Unmarshal(conf *confmap.Conf) error {
+	if conf.IsSet(echoKey) && cfg.Echo == nil {
+		echo := confighttp.NewDefaultClientConfig()
+		echo.Timeout = defaultEchoTimeout
+		cfg.Echo = &echo
+	}
+
 	err := conf.Unmarshal(cfg)
 	if err != nil {
 		return err
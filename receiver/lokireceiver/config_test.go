@@ -6,6 +6,7 @@ package lokireceiver
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,6 +44,18 @@ func TestLoadConfig(t *testing.T) {
 						Endpoint: "localhost:3500",
 					},
 				},
+				PushPaths: []string{"/loki/api/v1/push"},
+				TraceContext: TraceContextConfig{
+					TraceIDKey: "trace_id",
+					SpanIDKey:  "span_id",
+				},
+				Limits: LimitsConfig{
+					MaxLineLengthPolicy: LineLengthPolicyTruncate,
+				},
+				Cardinality: CardinalityConfig{
+					Interval: time.Minute,
+				},
+				AckMode: AckModeAfterConsume,
 			},
 		},
 		{
@@ -54,12 +67,50 @@ func TestLoadConfig(t *testing.T) {
 							Endpoint:  "localhost:4600",
 							Transport: confignet.TransportTypeTCP,
 						},
+						MaxConcurrentStreams: 1000,
+						Keepalive: &configgrpc.KeepaliveServerConfig{
+							ServerParameters: &configgrpc.KeepaliveServerParameters{
+								MaxConnectionIdle: 5 * time.Minute,
+							},
+							EnforcementPolicy: &configgrpc.KeepaliveEnforcementPolicy{
+								MinTime:             10 * time.Second,
+								PermitWithoutStream: true,
+							},
+						},
 					},
 					HTTP: &confighttp.ServerConfig{
 						Endpoint: "localhost:4500",
 					},
 				},
 				KeepTimestamp: true,
+				PushPaths:     []string{"/loki/api/v1/push", "/ingest/loki/api/v1/push"},
+				TraceContext: TraceContextConfig{
+					Enabled:    true,
+					TraceIDKey: "traceID",
+					SpanIDKey:  "spanID",
+				},
+				PropagateW3CHeaders: true,
+				DebugSampleRate:     0.01,
+				Limits: LimitsConfig{
+					MaxLabelsPerStream:  30,
+					MaxLabelNameLength:  128,
+					MaxLabelValueLength: 512,
+					MaxEntriesPerPush:   10000,
+					MaxLineLength:       4096,
+					MaxLineLengthPolicy: LineLengthPolicyReject,
+				},
+				Cardinality: CardinalityConfig{
+					Enabled:       true,
+					Interval:      30 * time.Second,
+					WarnThreshold: 10000,
+				},
+				AckMode: AckModeImmediate,
+				Echo: func() *confighttp.ClientConfig {
+					cfg := confighttp.NewDefaultClientConfig()
+					cfg.Endpoint = "http://loki:3100/loki/api/v1/push"
+					cfg.Timeout = 5 * time.Second
+					return &cfg
+				}(),
 			},
 		},
 	}
@@ -91,6 +142,42 @@ func TestInvalidConfig(t *testing.T) {
 			id:  component.NewIDWithName(metadata.Type, "empty"),
 			err: "must specify at least one protocol when using the Loki receiver",
 		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "negative_limits"),
+			err: "limits must not be negative",
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "invalid_line_length_policy"),
+			err: "limits.max_line_length_policy must be one of [truncate, reject]. Specified value: drop",
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "invalid_ack_mode"),
+			err: "ack_mode must be one of [immediate, after_consume]. Specified value: async",
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "echo_without_endpoint"),
+			err: "echo.endpoint must be specified when echo is configured",
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "relative_push_path"),
+			err: `push path "loki/api/v1/push" must start with '/'`,
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "duplicate_push_path"),
+			err: `push path "/loki/api/v1/push" is specified more than once`,
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "invalid_debug_sample_rate"),
+			err: "debug_sample_rate must be between 0 and 1. Specified value: 2",
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "invalid_cardinality_interval"),
+			err: "cardinality.interval must be positive when cardinality is enabled",
+		},
+		{
+			id:  component.NewIDWithName(metadata.Type, "empty_trace_context_key"),
+			err: "trace_context.trace_id_key and trace_context.span_id_key must not be empty when trace_context is enabled",
+		},
 	}
 
 	for _, tt := range tests {
@@ -103,7 +190,7 @@ func TestInvalidConfig(t *testing.T) {
 			require.NoError(t, sub.Unmarshal(cfg))
 
 			err = xconfmap.Validate(cfg)
-			assert.Error(t, err, tt.err)
+			assert.EqualError(t, err, tt.err)
 		})
 	}
 }
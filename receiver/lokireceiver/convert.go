@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver"
+
+import (
+	"github.com/grafana/loki/pkg/push"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/loki"
+)
+
+// truncatedAttribute flags log records whose line was truncated to the maximum line length
+const truncatedAttribute = "loki.truncated"
+
+// convert translates the push request into logs and applies the receiver
+// specific processing on top of the translated log records. The header context
+// is only available for HTTP push requests and may be nil.
+func (r *lokiReceiver) convert(pushRequest *push.PushRequest, hc *headerContext) (plog.Logs, error) {
+	logs, err := loki.PushRequestToLogs(pushRequest, r.conf.KeepTimestamp)
+	if err != nil {
+		return logs, err
+	}
+
+	if r.conf.Limits.MaxLineLength > 0 && r.conf.Limits.MaxLineLengthPolicy == LineLengthPolicyTruncate {
+		forEachEntry(pushRequest, logs, func(entry *push.Entry, lr plog.LogRecord) {
+			if len(entry.Line) > r.conf.Limits.MaxLineLength {
+				lr.Body().SetStr(truncateLine(entry.Line, r.conf.Limits.MaxLineLength))
+				lr.Attributes().PutBool(truncatedAttribute, true)
+			}
+		})
+	}
+
+	if hc != nil {
+		rls := logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					hc.apply(lrs.At(k))
+				}
+			}
+		}
+	}
+
+	// The trace context from structured metadata is specific to the entry,
+	// so it takes precedence over the one propagated through the headers.
+	if r.conf.TraceContext.Enabled {
+		forEachEntry(pushRequest, logs, func(entry *push.Entry, lr plog.LogRecord) {
+			setTraceContextFromStructuredMetadata(entry, lr, r.conf.TraceContext)
+		})
+	}
+
+	if r.sampleDebug() {
+		r.logDebugSample(pushRequest, logs)
+	}
+	return logs, nil
+}
+
+// forEachEntry calls fn for every entry of the push request together with the
+// log record it was translated to. It must only be used when the translation
+// succeeded, as the translator then keeps every entry in order.
+func forEachEntry(pushRequest *push.PushRequest, logs plog.Logs, fn func(*push.Entry, plog.LogRecord)) {
+	if logs.ResourceLogs().Len() == 0 {
+		return
+	}
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+
+	var i int
+	for _, stream := range pushRequest.Streams {
+		for j := range stream.Entries {
+			if i >= records.Len() {
+				return
+			}
+			fn(&stream.Entries[j], records.At(i))
+			i++
+		}
+	}
+}
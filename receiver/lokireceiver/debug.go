@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver"
+
+import (
+	"math/rand/v2"
+
+	"github.com/grafana/loki/pkg/push"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// Debug samples are limited, so sampling a large push request does not flood the logs.
+const (
+	debugSampleMaxStreams    = 10
+	debugSampleMaxRecords    = 10
+	debugSampleMaxLineLength = 256
+)
+
+type debugStream struct {
+	Labels                 string   `json:"labels"`
+	Entries                int      `json:"entries"`
+	StructuredMetadataKeys []string `json:"structured_metadata_keys,omitempty"`
+}
+
+type debugLogRecord struct {
+	Timestamp  string         `json:"timestamp"`
+	Body       string         `json:"body"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// sampleDebug returns whether the push request should be logged as a debug sample.
+func (r *lokiReceiver) sampleDebug() bool {
+	return r.conf.DebugSampleRate > 0 &&
+		r.settings.Logger.Core().Enabled(zap.DebugLevel) &&
+		rand.Float64() < r.conf.DebugSampleRate
+}
+
+// logDebugSample logs the decoded streams of the push request next to the log records they
+// were converted to. Only the keys of structured metadata are logged and lines are truncated.
+func (r *lokiReceiver) logDebugSample(pushRequest *push.PushRequest, logs plog.Logs) {
+	streams := make([]debugStream, 0, min(len(pushRequest.Streams), debugSampleMaxStreams))
+	for _, stream := range pushRequest.Streams {
+		if len(streams) == debugSampleMaxStreams {
+			break
+		}
+		s := debugStream{Labels: stream.Labels, Entries: len(stream.Entries)}
+		seen := make(map[string]struct{})
+		for _, entry := range stream.Entries {
+			for _, label := range entry.StructuredMetadata {
+				if _, ok := seen[label.Name]; !ok {
+					seen[label.Name] = struct{}{}
+					s.StructuredMetadataKeys = append(s.StructuredMetadataKeys, label.Name)
+				}
+			}
+		}
+		streams = append(streams, s)
+	}
+
+	var records []debugLogRecord
+	if logs.ResourceLogs().Len() > 0 {
+		lrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		for i := 0; i < lrs.Len() && i < debugSampleMaxRecords; i++ {
+			lr := lrs.At(i)
+			records = append(records, debugLogRecord{
+				Timestamp:  lr.Timestamp().String(),
+				Body:       truncateLine(lr.Body().AsString(), debugSampleMaxLineLength),
+				Attributes: lr.Attributes().AsRaw(),
+			})
+		}
+	}
+
+	r.settings.Logger.Debug("sampled push request",
+		zap.Int("streams_count", len(pushRequest.Streams)),
+		zap.Any("streams", streams),
+		zap.Int("log_records_count", logs.LogRecordCount()),
+		zap.Any("log_records", records),
+	)
+}
@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadata"
+)
+
+func TestDebugSample(t *testing.T) {
+	pushRequest := &push.PushRequest{
+		Streams: []push.Stream{
+			{
+				Labels: `{foo="bar"}`,
+				Entries: []push.Entry{
+					{
+						Timestamp: time.Unix(0, 1676888496000000000),
+						Line:      strings.Repeat("a", 1000),
+						StructuredMetadata: push.LabelsAdapter{
+							{Name: "secret", Value: "do-not-log"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		sampleRate float64
+		level      zapcore.Level
+		expected   int
+	}{
+		{
+			name:       "disabled",
+			sampleRate: 0,
+			level:      zap.DebugLevel,
+			expected:   0,
+		},
+		{
+			name:       "debug level not enabled",
+			sampleRate: 1,
+			level:      zap.InfoLevel,
+			expected:   0,
+		},
+		{
+			name:       "sampled",
+			sampleRate: 1,
+			level:      zap.DebugLevel,
+			expected:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(tt.level)
+			cfg := createDefaultConfig().(*Config)
+			cfg.DebugSampleRate = tt.sampleRate
+			settings := receivertest.NewNopSettings(metadata.Type)
+			settings.Logger = zap.New(core)
+			r, err := newLokiReceiver(cfg, consumertest.NewNop(), settings)
+			require.NoError(t, err)
+
+			_, err = r.convert(pushRequest, nil)
+			require.NoError(t, err)
+
+			sampled := logs.FilterMessage("sampled push request").All()
+			require.Len(t, sampled, tt.expected)
+			if tt.expected == 0 {
+				return
+			}
+
+			fields := sampled[0].ContextMap()
+			assert.Equal(t, int64(1), fields["streams_count"])
+			assert.Equal(t, []debugStream{{Labels: `{foo="bar"}`, Entries: 1, StructuredMetadataKeys: []string{"secret"}}}, fields["streams"])
+			records, ok := fields["log_records"].([]debugLogRecord)
+			require.True(t, ok)
+			require.Len(t, records, 1)
+			assert.Len(t, records[0].Body, debugSampleMaxLineLength)
+			assert.Equal(t, map[string]any{"foo": "bar"}, records[0].Attributes)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/loki/pkg/push"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/zap"
+)
+
+// echoQueueSize is the number of push requests waiting to be echoed,
+// further requests are dropped until the queue drains.
+const echoQueueSize = 1024
+
+// echoer forwards accepted push requests to a secondary Loki compatible endpoint.
+// Echoing is best effort and never affects the response sent to the client.
+type echoer struct {
+	config *confighttp.ClientConfig
+	logger *zap.Logger
+	client *http.Client
+	queue  chan *push.PushRequest
+	wg     sync.WaitGroup
+
+	// ctx is canceled on shutdown, aborting the in-flight request and dropping queued ones.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newEchoer(config *confighttp.ClientConfig, logger *zap.Logger) *echoer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &echoer{
+		config: config,
+		logger: logger,
+		queue:  make(chan *push.PushRequest, echoQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (e *echoer) start(ctx context.Context, host component.Host, settings component.TelemetrySettings) error {
+	var err error
+	e.client, err = e.config.ToClient(ctx, host, settings)
+	if err != nil {
+		return err
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case pushRequest := <-e.queue:
+				if err := e.send(e.ctx, pushRequest); err != nil && e.ctx.Err() == nil {
+					e.logger.Warn("failed to echo push request", zap.String("endpoint", e.config.Endpoint), zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (e *echoer) echo(pushRequest *push.PushRequest) {
+	if e.ctx.Err() != nil {
+		return
+	}
+	select {
+	case e.queue <- pushRequest:
+	default:
+		e.logger.Warn("echo queue is full, dropping push request", zap.String("endpoint", e.config.Endpoint))
+	}
+}
+
+func (e *echoer) send(ctx context.Context, pushRequest *push.PushRequest) error {
+	buf, err := pushRequest.Marshal()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Endpoint, bytes.NewReader(snappy.Encode(nil, buf)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", pbContentType)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// shutdown aborts the in-flight request and drops the push requests still queued,
+// so a slow or unresponsive echo endpoint does not delay the collector shutdown.
+func (e *echoer) shutdown() {
+	e.cancel()
+	e.wg.Wait()
+	if dropped := len(e.queue); dropped > 0 {
+		e.logger.Info("dropped queued push requests on shutdown", zap.String("endpoint", e.config.Endpoint), zap.Int("dropped", dropped))
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/common/testutil"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadata"
+)
+
+func TestEchoPushRequest(t *testing.T) {
+	echoed := make(chan *push.PushRequest, 1)
+	echoServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, pbContentType, req.Header.Get("Content-Type"))
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		decoded, err := snappy.Decode(nil, body)
+		assert.NoError(t, err)
+		var pushRequest push.PushRequest
+		assert.NoError(t, pushRequest.Unmarshal(decoded))
+		echoed <- &pushRequest
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer echoServer.Close()
+
+	addr := testutil.GetAvailableLocalAddress(t)
+	echoConfig := confighttp.NewDefaultClientConfig()
+	echoConfig.Endpoint = echoServer.URL + "/loki/api/v1/push"
+	config := &Config{
+		Protocols: Protocols{
+			HTTP: &confighttp.ServerConfig{
+				Endpoint: addr,
+			},
+		},
+		Echo: &echoConfig,
+	}
+	sink := new(consumertest.LogsSink)
+	lr, err := newLokiReceiver(config, sink, receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+
+	require.NoError(t, lr.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() { require.NoError(t, lr.Shutdown(context.Background())) })
+
+	_, port, _ := net.SplitHostPort(addr)
+	collectorAddr := fmt.Sprintf("http://localhost:%s/loki/api/v1/push", port)
+	require.NoError(t, sendToCollector(collectorAddr, jsonContentType, "", []byte(`{"streams": [{"stream": {"foo": "bar"},"values": [[ "1676888496000000000", "logline 1" ]]}]}`)))
+	require.Equal(t, 1, sink.LogRecordCount())
+
+	select {
+	case pushRequest := <-echoed:
+		require.Len(t, pushRequest.Streams, 1)
+		assert.Equal(t, `{foo="bar"}`, pushRequest.Streams[0].Labels)
+		require.Len(t, pushRequest.Streams[0].Entries, 1)
+		assert.Equal(t, "logline 1", pushRequest.Streams[0].Entries[0].Line)
+		assert.Equal(t, int64(1676888496000000000), pushRequest.Streams[0].Entries[0].Timestamp.UnixNano())
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "push request was not echoed")
+	}
+}
+
+func TestEchoShutdownWithUnresponsiveEndpoint(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	echoServer := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		received <- struct{}{}
+		select {
+		case <-req.Context().Done():
+		case <-release:
+		}
+	}))
+	defer echoServer.Close()
+	defer close(release)
+
+	echoConfig := confighttp.NewDefaultClientConfig()
+	echoConfig.Endpoint = echoServer.URL + "/loki/api/v1/push"
+	e := newEchoer(&echoConfig, zap.NewNop())
+	require.NoError(t, e.start(context.Background(), componenttest.NewNopHost(), componenttest.NewNopTelemetrySettings()))
+
+	for i := 0; i < 10; i++ {
+		e.echo(&push.PushRequest{})
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "push request was not echoed")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "shutdown did not abort the in-flight echo request")
+	}
+
+	// push requests echoed after shutdown are ignored
+	e.echo(&push.PushRequest{})
+}
@@ -7,6 +7,7 @@ package lokireceiver // import "github.com/open-telemetry/opentelemetry-collecto
 
 import (
 	"context"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configgrpc"
@@ -21,6 +22,15 @@ import (
 const (
 	defaultGRPCEndpoint = "localhost:3600"
 	defaultHTTPEndpoint = "localhost:3500"
+	defaultPushPath     = "/loki/api/v1/push"
+	defaultTraceIDKey   = "trace_id"
+	defaultSpanIDKey    = "span_id"
+
+	defaultCardinalityInterval = time.Minute
+
+	// defaultEchoTimeout bounds each echoed request, so an unresponsive echo
+	// endpoint cannot stall the forwarding of the following push requests.
+	defaultEchoTimeout = 5 * time.Second
 )
 
 // NewFactory return a new receiver.Factory for loki receiver.
@@ -44,6 +54,18 @@ func createDefaultConfig() component.Config {
 				Endpoint: defaultHTTPEndpoint,
 			},
 		},
+		PushPaths: []string{defaultPushPath},
+		TraceContext: TraceContextConfig{
+			TraceIDKey: defaultTraceIDKey,
+			SpanIDKey:  defaultSpanIDKey,
+		},
+		Limits: LimitsConfig{
+			MaxLineLengthPolicy: LineLengthPolicyTruncate,
+		},
+		Cardinality: CardinalityConfig{
+			Interval: defaultCardinalityInterval,
+		},
+		AckMode: AckModeAfterConsume,
 	}
 }
 
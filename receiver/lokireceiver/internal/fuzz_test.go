@@ -10,7 +10,11 @@ import (
 )
 
 func FuzzParseRequest(f *testing.F) {
-	f.Fuzz(func(t *testing.T, data []byte, headerType uint8) {
+	f.Add([]byte(`{"streams": [{"stream": {"foo": "bar"},"values": [[ "1676888496000000000", "logline 1" ]]}]}`), uint8(0), true)
+	f.Add([]byte(`{"streams": [{"stream": {"foo": "bar"},"values": [[ "1676888496000000000", "logline 1", {"trace_id": "abc"} ]]}]}`), uint8(0), true)
+	f.Add([]byte(`{"streams": [{"stream": {"foo": 1},"values": [[ 1676888496000000000 ], null, []]}]}`), uint8(0), true)
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, uint8(0), false)
+	f.Fuzz(func(t *testing.T, data []byte, headerType uint8, jsonContent bool) {
 		req, err := http.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(data))
 		if err != nil {
 			t.Skip()
@@ -21,7 +25,12 @@ func FuzzParseRequest(f *testing.F) {
 		case 1:
 			req.Header.Add("Content-Encoding", "gzip")
 		case 2:
-			req.Header.Add("Content-Encoding", "deflat")
+			req.Header.Add("Content-Encoding", "deflate")
+		}
+		if jsonContent {
+			req.Header.Add("Content-Type", applicationJSON)
+		} else {
+			req.Header.Add("Content-Type", "application/x-protobuf")
 		}
 		_, _ = ParseRequest(req)
 	})
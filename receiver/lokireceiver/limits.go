@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver"
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/prometheus/model/labels"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+)
+
+// validatePushRequest checks the push request against the configured limits,
+// so a single malformed or malicious request cannot exhaust the receiver.
+func validatePushRequest(pushRequest *push.PushRequest, limits LimitsConfig) error {
+	var entries int
+	for _, stream := range pushRequest.Streams {
+		entries += len(stream.Entries)
+		if limits.MaxEntriesPerPush > 0 && entries > limits.MaxEntriesPerPush {
+			return fmt.Errorf("push request has more than %d entries", limits.MaxEntriesPerPush)
+		}
+		if limits.MaxLineLength > 0 && limits.MaxLineLengthPolicy == LineLengthPolicyReject {
+			for _, entry := range stream.Entries {
+				if len(entry.Line) > limits.MaxLineLength {
+					return fmt.Errorf("entry line is %d bytes long, more than the maximum of %d", len(entry.Line), limits.MaxLineLength)
+				}
+			}
+		}
+
+		if limits.MaxLabelsPerStream == 0 && limits.MaxLabelNameLength == 0 && limits.MaxLabelValueLength == 0 {
+			continue
+		}
+		ls, err := promql_parser.ParseMetric(stream.Labels)
+		if err != nil {
+			// Leave the error to be reported by the conversion.
+			continue
+		}
+		if limits.MaxLabelsPerStream > 0 && ls.Len() > limits.MaxLabelsPerStream {
+			return fmt.Errorf("stream has %d labels, more than the maximum of %d", ls.Len(), limits.MaxLabelsPerStream)
+		}
+		ls.Range(func(l labels.Label) {
+			if err != nil {
+				return
+			}
+			if limits.MaxLabelNameLength > 0 && len(l.Name) > limits.MaxLabelNameLength {
+				err = fmt.Errorf("label name is %d bytes long, more than the maximum of %d", len(l.Name), limits.MaxLabelNameLength)
+			} else if limits.MaxLabelValueLength > 0 && len(l.Value) > limits.MaxLabelValueLength {
+				err = fmt.Errorf("value of label %q is %d bytes long, more than the maximum of %d", l.Name, len(l.Value), limits.MaxLabelValueLength)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncateLine shortens the line to at most maxLength bytes without splitting a UTF-8 encoded rune.
+func truncateLine(line string, maxLength int) string {
+	if len(line) <= maxLength {
+		return line
+	}
+	n := maxLength
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	return line[:n]
+}
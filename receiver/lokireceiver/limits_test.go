@@ -0,0 +1,162 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadata"
+)
+
+func TestValidatePushRequest(t *testing.T) {
+	limits := LimitsConfig{
+		MaxLabelsPerStream:  2,
+		MaxLabelNameLength:  8,
+		MaxLabelValueLength: 8,
+		MaxEntriesPerPush:   2,
+	}
+	entry := push.Entry{Line: "logline"}
+
+	tests := []struct {
+		name    string
+		streams []push.Stream
+		limits  LimitsConfig
+		err     string
+	}{
+		{
+			name: "within limits",
+			streams: []push.Stream{
+				{Labels: `{foo="bar", baz="qux"}`, Entries: []push.Entry{entry}},
+				{Labels: `{foo="bar"}`, Entries: []push.Entry{entry}},
+			},
+			limits: limits,
+		},
+		{
+			name: "too many entries",
+			streams: []push.Stream{
+				{Labels: `{foo="bar"}`, Entries: []push.Entry{entry, entry}},
+				{Labels: `{foo="bar"}`, Entries: []push.Entry{entry}},
+			},
+			limits: limits,
+			err:    "push request has more than 2 entries",
+		},
+		{
+			name: "too many labels",
+			streams: []push.Stream{
+				{Labels: `{a="1", b="2", c="3"}`, Entries: []push.Entry{entry}},
+			},
+			limits: limits,
+			err:    "stream has 3 labels, more than the maximum of 2",
+		},
+		{
+			name: "label name too long",
+			streams: []push.Stream{
+				{Labels: `{very_long_name="1"}`, Entries: []push.Entry{entry}},
+			},
+			limits: limits,
+			err:    "label name is 14 bytes long, more than the maximum of 8",
+		},
+		{
+			name: "label value too long",
+			streams: []push.Stream{
+				{Labels: `{foo="very long value"}`, Entries: []push.Entry{entry}},
+			},
+			limits: limits,
+			err:    `value of label "foo" is 15 bytes long, more than the maximum of 8`,
+		},
+		{
+			name: "line too long",
+			streams: []push.Stream{
+				{Labels: `{foo="bar"}`, Entries: []push.Entry{{Line: "a very long line"}}},
+			},
+			limits: LimitsConfig{MaxLineLength: 8, MaxLineLengthPolicy: LineLengthPolicyReject},
+			err:    "entry line is 16 bytes long, more than the maximum of 8",
+		},
+		{
+			name: "long lines are truncated later",
+			streams: []push.Stream{
+				{Labels: `{foo="bar"}`, Entries: []push.Entry{{Line: "a very long line"}}},
+			},
+			limits: LimitsConfig{MaxLineLength: 8, MaxLineLengthPolicy: LineLengthPolicyTruncate},
+		},
+		{
+			name: "limits disabled",
+			streams: []push.Stream{
+				{Labels: `{a="1", b="2", very_long_name="very long value"}`, Entries: []push.Entry{entry, entry, entry}},
+			},
+		},
+		{
+			name: "invalid labels are left to the conversion",
+			streams: []push.Stream{
+				{Labels: `{foo=`, Entries: []push.Entry{entry}},
+			},
+			limits: limits,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePushRequest(&push.PushRequest{Streams: tt.streams}, tt.limits)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTruncateLine(t *testing.T) {
+	assert.Equal(t, "short", truncateLine("short", 8))
+	assert.Equal(t, "exactly8", truncateLine("exactly8", 8))
+	assert.Equal(t, "a very l", truncateLine("a very long line", 8))
+	// "é" is encoded in two bytes and must not be split
+	assert.Equal(t, "abcdef", truncateLine("abcdefé", 7))
+	assert.Empty(t, truncateLine("é", 1))
+}
+
+func TestTruncateLongLines(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Limits.MaxLineLength = 8
+	r, err := newLokiReceiver(cfg, consumertest.NewNop(), receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+
+	logs, err := r.convert(&push.PushRequest{
+		Streams: []push.Stream{
+			{
+				Labels:  `{foo="bar"}`,
+				Entries: []push.Entry{{Line: "a very long line"}, {Line: "short"}},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+
+	assert.Equal(t, "a very l", records.At(0).Body().Str())
+	truncated, ok := records.At(0).Attributes().Get("loki.truncated")
+	require.True(t, ok)
+	assert.True(t, truncated.Bool())
+
+	assert.Equal(t, "short", records.At(1).Body().Str())
+	_, ok = records.At(1).Attributes().Get("loki.truncated")
+	assert.False(t, ok)
+}
+
+func FuzzValidatePushRequest(f *testing.F) {
+	f.Add(`{foo="bar"}`, 1)
+	f.Add(`{foo="bar", foo="baz"}`, 2)
+	f.Add(`{`+strings.Repeat(`a`, 2000)+`="b"}`, 0)
+	f.Fuzz(func(_ *testing.T, labels string, entries int) {
+		stream := push.Stream{Labels: labels, Entries: make([]push.Entry, entries%100+100)}
+		_ = validatePushRequest(&push.PushRequest{Streams: []push.Stream{stream}}, createDefaultConfig().(*Config).Limits)
+	})
+}
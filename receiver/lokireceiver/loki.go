@@ -16,14 +16,17 @@ import (
 	"go.opentelemetry.io/collector/component/componentstatus"
 	"go.opentelemetry.io/collector/config/confighttp"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/collector/receiver/receiverhelper"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/errorutil"
-	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/loki"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadata"
 )
 
 const (
@@ -31,8 +34,14 @@ const (
 	jsonContentType = "application/json"
 )
 
+// maxPendingAsyncConsumes is the number of acknowledged push requests which may wait for the
+// next consumer in the immediate ack mode, further push requests are rejected until they drain.
+const maxPendingAsyncConsumes = 256
+
 const ErrAtLeastOneEntryFailedToProcess = "at least one entry in the push request failed to process"
 
+var errTooManyPendingConsumes = errors.New("too many acknowledged push requests are pending consumption, retry later")
+
 type lokiReceiver struct {
 	conf         *Config
 	nextConsumer consumer.Logs
@@ -41,6 +50,15 @@ type lokiReceiver struct {
 	serverHTTP   *http.Server
 	serverGRPC   *grpc.Server
 	shutdownWG   sync.WaitGroup
+	consumeWG    sync.WaitGroup
+	echoer       *echoer
+	cardinality  *cardinalityTracker
+
+	// pendingConsumes limits the acknowledged push requests waiting for the next consumer
+	// and cancelConsumes aborts them when the shutdown deadline expires.
+	pendingConsumes chan struct{}
+	consumesCtx     context.Context
+	cancelConsumes  context.CancelFunc
 
 	obsrepGRPC *receiverhelper.ObsReport
 	obsrepHTTP *receiverhelper.ObsReport
@@ -48,10 +66,12 @@ type lokiReceiver struct {
 
 func newLokiReceiver(conf *Config, nextConsumer consumer.Logs, settings receiver.Settings) (*lokiReceiver, error) {
 	r := &lokiReceiver{
-		conf:         conf,
-		nextConsumer: nextConsumer,
-		settings:     settings,
+		conf:            conf,
+		nextConsumer:    nextConsumer,
+		settings:        settings,
+		pendingConsumes: make(chan struct{}, maxPendingAsyncConsumes),
 	}
+	r.consumesCtx, r.cancelConsumes = context.WithCancel(context.Background())
 
 	var err error
 	r.obsrepGRPC, err = receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
@@ -71,20 +91,27 @@ func newLokiReceiver(conf *Config, nextConsumer consumer.Logs, settings receiver
 		return nil, err
 	}
 
+	if conf.Echo != nil {
+		r.echoer = newEchoer(conf.Echo, settings.Logger)
+	}
+
+	if conf.Cardinality.Enabled {
+		telemetryBuilder, err := metadata.NewTelemetryBuilder(settings.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+		r.cardinality = newCardinalityTracker(conf.Cardinality, settings.Logger, telemetryBuilder)
+	}
+
 	if conf.HTTP != nil {
 		r.httpMux = http.NewServeMux()
-		r.httpMux.HandleFunc("/loki/api/v1/push", func(resp http.ResponseWriter, req *http.Request) {
-			if req.Method != http.MethodPost {
-				handleUnmatchedMethod(resp)
-				return
-			}
-			switch req.Header.Get("Content-Type") {
-			case jsonContentType, pbContentType:
-				handleLogs(resp, req, r)
-			default:
-				handleUnmatchedContentType(resp)
-			}
-		})
+		pushPaths := conf.PushPaths
+		if len(pushPaths) == 0 {
+			pushPaths = []string{defaultPushPath}
+		}
+		for _, path := range pushPaths {
+			r.httpMux.HandleFunc(path, r.handlePush)
+		}
 	}
 
 	return r, nil
@@ -92,6 +119,16 @@ func newLokiReceiver(conf *Config, nextConsumer consumer.Logs, settings receiver
 
 func (r *lokiReceiver) startProtocolsServers(ctx context.Context, host component.Host) error {
 	var err error
+	if r.echoer != nil {
+		if err = r.echoer.start(ctx, host, r.settings.TelemetrySettings); err != nil {
+			return fmt.Errorf("failed to create echo client error: %w", err)
+		}
+	}
+
+	if r.cardinality != nil {
+		r.cardinality.start()
+	}
+
 	if r.conf.HTTP != nil {
 		r.serverHTTP, err = r.conf.HTTP.ToServer(ctx, host, r.settings.TelemetrySettings, r.httpMux, confighttp.WithDecoder("snappy", func(body io.ReadCloser) (io.ReadCloser, error) { return body, nil }))
 		if err != nil {
@@ -155,21 +192,70 @@ func (r *lokiReceiver) startGRPCServer(ctx context.Context, host component.Host)
 }
 
 func (r *lokiReceiver) Push(ctx context.Context, pushRequest *push.PushRequest) (*push.PushResponse, error) {
-	logs, err := loki.PushRequestToLogs(pushRequest, r.conf.KeepTimestamp)
+	if err := validatePushRequest(pushRequest, r.conf.Limits); err != nil {
+		return &push.PushResponse{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	logs, err := r.convert(pushRequest, nil)
 	if err != nil {
 		r.settings.Logger.Warn(ErrAtLeastOneEntryFailedToProcess, zap.Error(err))
 		return &push.PushResponse{}, err
 	}
-	ctx = r.obsrepGRPC.StartLogsOp(ctx)
-	logRecordCount := logs.LogRecordCount()
-	err = r.nextConsumer.ConsumeLogs(ctx, logs)
-	r.obsrepGRPC.EndLogsOp(ctx, "protobuf", logRecordCount, err)
-	if err != nil {
+	if r.cardinality != nil {
+		r.cardinality.observe(pushRequest)
+	}
+	if r.conf.AckMode == AckModeImmediate {
+		if !r.consumeAsync(ctx, r.obsrepGRPC, "protobuf", pushRequest, logs) {
+			return &push.PushResponse{}, status.Error(codes.ResourceExhausted, errTooManyPendingConsumes.Error())
+		}
+		return &push.PushResponse{}, nil
+	}
+	if err = r.consume(ctx, r.obsrepGRPC, "protobuf", pushRequest, logs); err != nil {
 		return &push.PushResponse{}, errorutil.GrpcError(err)
 	}
 	return &push.PushResponse{}, nil
 }
 
+// consume passes the log records to the next consumer and, once they are accepted,
+// forwards the push request to the echo endpoint.
+func (r *lokiReceiver) consume(ctx context.Context, obsrep *receiverhelper.ObsReport, format string, pushRequest *push.PushRequest, logs plog.Logs) error {
+	ctx = obsrep.StartLogsOp(ctx)
+	logRecordCount := logs.LogRecordCount()
+	err := r.nextConsumer.ConsumeLogs(ctx, logs)
+	obsrep.EndLogsOp(ctx, format, logRecordCount, err)
+	if err != nil {
+		return err
+	}
+	if r.echoer != nil {
+		r.echoer.echo(pushRequest)
+	}
+	return nil
+}
+
+// consumeAsync consumes the log records of a push request in the background and returns whether
+// the push request can be acknowledged, which is not the case when too many are already pending.
+// The request context is detached from its cancellation, as it ends once the response is sent.
+func (r *lokiReceiver) consumeAsync(ctx context.Context, obsrep *receiverhelper.ObsReport, format string, pushRequest *push.PushRequest, logs plog.Logs) bool {
+	select {
+	case r.pendingConsumes <- struct{}{}:
+	default:
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(r.consumesCtx, cancel)
+	r.consumeWG.Add(1)
+	go func() {
+		defer r.consumeWG.Done()
+		defer func() { <-r.pendingConsumes }()
+		defer stop()
+		defer cancel()
+		if err := r.consume(ctx, obsrep, format, pushRequest, logs); err != nil {
+			r.settings.Logger.Warn("failed to consume acknowledged push request", zap.Error(err))
+		}
+	}()
+	return true
+}
+
 func (r *lokiReceiver) Start(ctx context.Context, host component.Host) error {
 	return r.startProtocolsServers(ctx, host)
 }
@@ -186,9 +272,50 @@ func (r *lokiReceiver) Shutdown(ctx context.Context) error {
 	}
 
 	r.shutdownWG.Wait()
+	if waitErr := r.waitPendingConsumes(ctx); waitErr != nil {
+		err = errors.Join(err, waitErr)
+	}
+	if r.echoer != nil {
+		r.echoer.shutdown()
+	}
+	if r.cardinality != nil {
+		r.cardinality.shutdown()
+		r.cardinality.telemetryBuilder.Shutdown()
+	}
 	return err
 }
 
+// waitPendingConsumes waits for the acknowledged push requests to be consumed,
+// canceling the ones still pending when the context is done.
+func (r *lokiReceiver) waitPendingConsumes(ctx context.Context) error {
+	defer r.cancelConsumes()
+	done := make(chan struct{})
+	go func() {
+		r.consumeWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to consume all acknowledged push requests: %w", ctx.Err())
+	}
+}
+
+func (r *lokiReceiver) handlePush(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		handleUnmatchedMethod(resp)
+		return
+	}
+	switch req.Header.Get("Content-Type") {
+	case jsonContentType, pbContentType:
+		handleLogs(resp, req, r)
+	default:
+		handleUnmatchedContentType(resp)
+	}
+}
+
 func handleUnmatchedMethod(resp http.ResponseWriter) {
 	status := http.StatusMethodNotAllowed
 	writeResponse(resp, "text/plain", status, []byte(fmt.Sprintf("%v method not allowed, supported: [POST]", status)))
@@ -213,17 +340,34 @@ func handleLogs(resp http.ResponseWriter, req *http.Request, r *lokiReceiver) {
 		return
 	}
 
-	logs, err := loki.PushRequestToLogs(pushRequest, r.conf.KeepTimestamp)
+	if err = validatePushRequest(pushRequest, r.conf.Limits); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var hc *headerContext
+	if r.conf.PropagateW3CHeaders {
+		hc = extractHeaderContext(req.Header)
+	}
+
+	logs, err := r.convert(pushRequest, hc)
 	if err != nil {
 		r.settings.Logger.Warn(ErrAtLeastOneEntryFailedToProcess, zap.Error(err))
 		http.Error(resp, err.Error(), http.StatusBadRequest)
 		return
 	}
-	ctx := r.obsrepHTTP.StartLogsOp(req.Context())
-	logRecordCount := logs.LogRecordCount()
-	err = r.nextConsumer.ConsumeLogs(ctx, logs)
-	r.obsrepHTTP.EndLogsOp(ctx, "json", logRecordCount, err)
-	if err != nil {
+	if r.cardinality != nil {
+		r.cardinality.observe(pushRequest)
+	}
+	if r.conf.AckMode == AckModeImmediate {
+		if !r.consumeAsync(req.Context(), r.obsrepHTTP, "json", pushRequest, logs) {
+			http.Error(resp, errTooManyPendingConsumes.Error(), http.StatusTooManyRequests)
+			return
+		}
+		resp.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err = r.consume(req.Context(), r.obsrepHTTP, "json", pushRequest, logs); err != nil {
 		errorutil.HTTPError(resp, err)
 		return
 	}
@@ -24,13 +24,16 @@ import (
 	"go.opentelemetry.io/collector/config/configgrpc"
 	"go.opentelemetry.io/collector/config/confighttp"
 	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/receiver/receivertest"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/common/testutil"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/plogtest"
@@ -438,6 +441,218 @@ func TestExpectedStatus(t *testing.T) {
 	}
 }
 
+func TestAckModeImmediate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		consumer *consumertest.LogsSink
+		err      error
+	}{
+		{
+			name:     "success",
+			consumer: new(consumertest.LogsSink),
+		},
+		{
+			name: "consumer-error",
+			err:  errors.New("non-permanent"),
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			httpAddr := testutil.GetAvailableLocalAddress(t)
+			config := &Config{
+				Protocols: Protocols{
+					GRPC: &configgrpc.ServerConfig{
+						NetAddr: confignet.AddrConfig{
+							Endpoint:  testutil.GetAvailableLocalAddress(t),
+							Transport: confignet.TransportTypeTCP,
+						},
+					},
+					HTTP: &confighttp.ServerConfig{
+						Endpoint: httpAddr,
+					},
+				},
+				AckMode: AckModeImmediate,
+			}
+
+			var nextConsumer consumer.Logs = tt.consumer
+			if tt.err != nil {
+				nextConsumer = consumertest.NewErr(tt.err)
+			}
+			lr, err := newLokiReceiver(config, nextConsumer, receivertest.NewNopSettings(metadata.Type))
+			require.NoError(t, err)
+
+			require.NoError(t, lr.Start(context.Background(), componenttest.NewNopHost()))
+			t.Cleanup(func() { require.NoError(t, lr.Shutdown(context.Background())) })
+			conn, err := grpc.NewClient(config.GRPC.NetAddr.Endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(t, err)
+			defer conn.Close()
+			grpcClient := push.NewPusherClient(conn)
+
+			body := &push.PushRequest{
+				Streams: []push.Stream{
+					{
+						Labels: "{foo=\"bar\"}",
+						Entries: []push.Entry{
+							{
+								Timestamp: time.Unix(0, 1676888496000000000),
+								Line:      "logline 1",
+							},
+						},
+					},
+				},
+			}
+
+			// Push requests are acknowledged regardless of the outcome of the next consumer.
+			_, err = grpcClient.Push(context.Background(), body)
+			require.NoError(t, err)
+
+			_, port, _ := net.SplitHostPort(httpAddr)
+			collectorAddr := fmt.Sprintf("http://localhost:%s/loki/api/v1/push", port)
+			require.NoError(t, sendToCollector(collectorAddr, "application/json", "", []byte(`{"streams": [{"stream": {"foo": "bar"},"values": [[ "1676888496000000000", "logline 1" ]]}]}`)))
+
+			if tt.consumer != nil {
+				assert.Eventually(t, func() bool {
+					return tt.consumer.LogRecordCount() == 2
+				}, time.Second, 10*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestAckModeImmediatePendingConsumes(t *testing.T) {
+	httpAddr := testutil.GetAvailableLocalAddress(t)
+	config := &Config{
+		Protocols: Protocols{
+			GRPC: &configgrpc.ServerConfig{
+				NetAddr: confignet.AddrConfig{
+					Endpoint:  testutil.GetAvailableLocalAddress(t),
+					Transport: confignet.TransportTypeTCP,
+				},
+			},
+			HTTP: &confighttp.ServerConfig{
+				Endpoint: httpAddr,
+			},
+		},
+		AckMode: AckModeImmediate,
+	}
+
+	// the next consumer blocks until the consume is canceled
+	nextConsumer, err := consumer.NewLogs(func(ctx context.Context, _ plog.Logs) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+	lr, err := newLokiReceiver(config, nextConsumer, receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+	lr.pendingConsumes = make(chan struct{}, 1)
+
+	require.NoError(t, lr.Start(context.Background(), componenttest.NewNopHost()))
+	conn, err := grpc.NewClient(config.GRPC.NetAddr.Endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	grpcClient := push.NewPusherClient(conn)
+
+	_, port, _ := net.SplitHostPort(httpAddr)
+	collectorAddr := fmt.Sprintf("http://localhost:%s/loki/api/v1/push", port)
+	body := []byte(`{"streams": [{"stream": {"foo": "bar"},"values": [[ "1676888496000000000", "logline 1" ]]}]}`)
+	require.NoError(t, sendToCollector(collectorAddr, "application/json", "", body))
+
+	// further push requests are rejected while the pending consume is blocked
+	require.EqualError(t, sendToCollector(collectorAddr, "application/json", "", body), "failed to upload logs; HTTP status code: 429")
+	_, err = grpcClient.Push(context.Background(), &push.PushRequest{
+		Streams: []push.Stream{
+			{
+				Labels:  "{foo=\"bar\"}",
+				Entries: []push.Entry{{Timestamp: time.Unix(0, 1676888496000000000), Line: "logline 1"}},
+			},
+		},
+	})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the pending consume is canceled once the shutdown deadline expires
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.ErrorContains(t, lr.Shutdown(ctx), "failed to consume all acknowledged push requests")
+	require.Eventually(t, func() bool { return len(lr.pendingConsumes) == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestPushRequestLimits(t *testing.T) {
+	httpAddr := testutil.GetAvailableLocalAddress(t)
+	config := &Config{
+		Protocols: Protocols{
+			GRPC: &configgrpc.ServerConfig{
+				NetAddr: confignet.AddrConfig{
+					Endpoint:  testutil.GetAvailableLocalAddress(t),
+					Transport: confignet.TransportTypeTCP,
+				},
+			},
+			HTTP: &confighttp.ServerConfig{
+				Endpoint: httpAddr,
+			},
+		},
+		Limits: LimitsConfig{
+			MaxLabelsPerStream: 1,
+		},
+	}
+	sink := new(consumertest.LogsSink)
+	lr, err := newLokiReceiver(config, sink, receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+
+	require.NoError(t, lr.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() { require.NoError(t, lr.Shutdown(context.Background())) })
+	conn, err := grpc.NewClient(config.GRPC.NetAddr.Endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	grpcClient := push.NewPusherClient(conn)
+
+	body := &push.PushRequest{
+		Streams: []push.Stream{
+			{
+				Labels: "{foo=\"bar\", bar=\"baz\"}",
+				Entries: []push.Entry{
+					{
+						Timestamp: time.Unix(0, 1676888496000000000),
+						Line:      "logline 1",
+					},
+				},
+			},
+		},
+	}
+	_, err = grpcClient.Push(context.Background(), body)
+	require.EqualError(t, err, "rpc error: code = InvalidArgument desc = stream has 2 labels, more than the maximum of 1")
+
+	_, port, _ := net.SplitHostPort(httpAddr)
+	collectorAddr := fmt.Sprintf("http://localhost:%s/loki/api/v1/push", port)
+	require.EqualError(t, sendToCollector(collectorAddr, "application/json", "", []byte(`{"streams": [{"stream": {"foo": "bar", "bar": "baz"},"values": [[ "1676888496000000000", "logline 1" ]]}]}`)), "failed to upload logs; HTTP status code: 400")
+	require.Empty(t, sink.AllLogs())
+}
+
+func TestCustomPushPaths(t *testing.T) {
+	addr := testutil.GetAvailableLocalAddress(t)
+	config := &Config{
+		Protocols: Protocols{
+			HTTP: &confighttp.ServerConfig{
+				Endpoint: addr,
+			},
+		},
+		PushPaths: []string{"/ingest/loki/api/v1/push", "/tenant/loki/api/v1/push"},
+	}
+	sink := new(consumertest.LogsSink)
+	lr, err := newLokiReceiver(config, sink, receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+
+	require.NoError(t, lr.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() { require.NoError(t, lr.Shutdown(context.Background())) })
+
+	_, port, _ := net.SplitHostPort(addr)
+	body := []byte(`{"streams": [{"stream": {"foo": "bar"},"values": [[ "1676888496000000000", "logline 1" ]]}]}`)
+	for _, path := range config.PushPaths {
+		require.NoError(t, sendToCollector(fmt.Sprintf("http://localhost:%s%s", port, path), jsonContentType, "", body))
+	}
+	require.EqualError(t, sendToCollector(fmt.Sprintf("http://localhost:%s/loki/api/v1/push", port), jsonContentType, "", body), "failed to upload logs; HTTP status code: 404")
+	require.Equal(t, 2, sink.LogRecordCount())
+}
+
 type Log struct {
 	Timestamp  int64
 	Body       pcommon.Value
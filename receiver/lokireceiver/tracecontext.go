@@ -0,0 +1,87 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver"
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/grafana/loki/pkg/push"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerContext holds the W3C trace context and baggage propagated
+// through the `traceparent` and `baggage` headers of an HTTP push request.
+type headerContext struct {
+	spanContext trace.SpanContext
+	baggage     baggage.Baggage
+}
+
+func extractHeaderContext(header http.Header) *headerContext {
+	carrier := propagation.HeaderCarrier(header)
+	ctx := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}).Extract(context.Background(), carrier)
+	return &headerContext{
+		spanContext: trace.SpanContextFromContext(ctx),
+		baggage:     baggage.FromContext(ctx),
+	}
+}
+
+// apply sets the propagated trace context on the log record and adds the baggage
+// members as attributes, without overriding attributes set from the stream labels.
+func (hc *headerContext) apply(lr plog.LogRecord) {
+	if hc.spanContext.IsValid() {
+		lr.SetTraceID(pcommon.TraceID(hc.spanContext.TraceID()))
+		lr.SetSpanID(pcommon.SpanID(hc.spanContext.SpanID()))
+		lr.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(hc.spanContext.IsSampled()))
+	}
+	for _, member := range hc.baggage.Members() {
+		if _, ok := lr.Attributes().Get(member.Key()); !ok {
+			lr.Attributes().PutStr(member.Key(), member.Value())
+		}
+	}
+}
+
+// setTraceContextFromStructuredMetadata sets the trace and span IDs of the log record
+// from the structured metadata of the entry. Invalid IDs are ignored.
+func setTraceContextFromStructuredMetadata(entry *push.Entry, lr plog.LogRecord, cfg TraceContextConfig) {
+	for _, l := range entry.StructuredMetadata {
+		switch l.Name {
+		case cfg.TraceIDKey:
+			if traceID, ok := parseTraceID(l.Value); ok {
+				lr.SetTraceID(traceID)
+			}
+		case cfg.SpanIDKey:
+			if spanID, ok := parseSpanID(l.Value); ok {
+				lr.SetSpanID(spanID)
+			}
+		}
+	}
+}
+
+func parseTraceID(s string) (pcommon.TraceID, bool) {
+	var id pcommon.TraceID
+	if len(s) != hex.EncodedLen(len(id)) {
+		return id, false
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return id, false
+	}
+	return id, !id.IsEmpty()
+}
+
+func parseSpanID(s string) (pcommon.SpanID, bool) {
+	var id pcommon.SpanID
+	if len(s) != hex.EncodedLen(len(id)) {
+		return id, false
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return id, false
+	}
+	return id, !id.IsEmpty()
+}
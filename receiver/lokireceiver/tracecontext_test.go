@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package lokireceiver
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/lokireceiver/internal/metadata"
+)
+
+func TestTraceContextFromStructuredMetadata(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.TraceContext.Enabled = true
+	r, err := newLokiReceiver(cfg, consumertest.NewNop(), receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+
+	pushRequest := &push.PushRequest{
+		Streams: []push.Stream{
+			{
+				Labels: `{foo="bar"}`,
+			},
+			{
+				Labels: `{foo="bar"}`,
+				Entries: []push.Entry{
+					{
+						Timestamp: time.Unix(0, 1676888496000000000),
+						Line:      "valid trace context",
+						StructuredMetadata: push.LabelsAdapter{
+							{Name: "trace_id", Value: "0102030405060708090a0b0c0d0e0f10"},
+							{Name: "span_id", Value: "0102030405060708"},
+						},
+					},
+					{
+						Timestamp: time.Unix(0, 1676888497000000000),
+						Line:      "no trace context",
+					},
+				},
+			},
+			{
+				Labels: `{foo="baz"}`,
+				Entries: []push.Entry{
+					{
+						Timestamp: time.Unix(0, 1676888498000000000),
+						Line:      "invalid trace context",
+						StructuredMetadata: push.LabelsAdapter{
+							{Name: "trace_id", Value: "not-a-trace-id"},
+							{Name: "span_id", Value: "0000000000000000"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	logs, err := r.convert(pushRequest, nil)
+	require.NoError(t, err)
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 3, records.Len())
+
+	assert.Equal(t, "valid trace context", records.At(0).Body().Str())
+	assert.Equal(t, pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, records.At(0).TraceID())
+	assert.Equal(t, pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8}, records.At(0).SpanID())
+
+	assert.Equal(t, "no trace context", records.At(1).Body().Str())
+	assert.True(t, records.At(1).TraceID().IsEmpty())
+	assert.True(t, records.At(1).SpanID().IsEmpty())
+
+	assert.Equal(t, "invalid trace context", records.At(2).Body().Str())
+	assert.True(t, records.At(2).TraceID().IsEmpty())
+	assert.True(t, records.At(2).SpanID().IsEmpty())
+}
+
+func TestTraceContextDisabled(t *testing.T) {
+	r, err := newLokiReceiver(createDefaultConfig().(*Config), consumertest.NewNop(), receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+
+	logs, err := r.convert(&push.PushRequest{
+		Streams: []push.Stream{
+			{
+				Labels: `{foo="bar"}`,
+				Entries: []push.Entry{
+					{
+						Line: "logline",
+						StructuredMetadata: push.LabelsAdapter{
+							{Name: "trace_id", Value: "0102030405060708090a0b0c0d0e0f10"},
+						},
+					},
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	assert.True(t, logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).TraceID().IsEmpty())
+}
+
+func TestTraceContextFromW3CHeaders(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.TraceContext.Enabled = true
+	cfg.PropagateW3CHeaders = true
+	r, err := newLokiReceiver(cfg, consumertest.NewNop(), receivertest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+	header.Set("baggage", "tenant=acme,foo=overridden")
+
+	logs, err := r.convert(&push.PushRequest{
+		Streams: []push.Stream{
+			{
+				Labels: `{foo="bar"}`,
+				Entries: []push.Entry{
+					{
+						Line: "propagated trace context",
+					},
+					{
+						Line: "entry trace context",
+						StructuredMetadata: push.LabelsAdapter{
+							{Name: "trace_id", Value: "100f0e0d0c0b0a090807060504030201"},
+							{Name: "span_id", Value: "0807060504030201"},
+						},
+					},
+				},
+			},
+		},
+	}, extractHeaderContext(header))
+	require.NoError(t, err)
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+
+	assert.Equal(t, pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, records.At(0).TraceID())
+	assert.Equal(t, pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8}, records.At(0).SpanID())
+	assert.True(t, records.At(0).Flags().IsSampled())
+	tenant, ok := records.At(0).Attributes().Get("tenant")
+	require.True(t, ok)
+	assert.Equal(t, "acme", tenant.Str())
+	foo, ok := records.At(0).Attributes().Get("foo")
+	require.True(t, ok)
+	assert.Equal(t, "bar", foo.Str())
+
+	assert.Equal(t, pcommon.TraceID{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}, records.At(1).TraceID())
+	assert.Equal(t, pcommon.SpanID{8, 7, 6, 5, 4, 3, 2, 1}, records.At(1).SpanID())
+}
+
+func TestExtractHeaderContextWithoutHeaders(t *testing.T) {
+	hc := extractHeaderContext(http.Header{})
+	assert.False(t, hc.spanContext.IsValid())
+	assert.Equal(t, 0, hc.baggage.Len())
+}